@@ -0,0 +1,109 @@
+package client
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffStrategy provides a basic interface for retry backoff
+type BackoffStrategy interface {
+	// NextDelay returns the delay before the retry following attempt, starting from 0
+	NextDelay(attempt int) time.Duration
+}
+
+// maxBackoffDelay bounds computed delays, so they neither overflow nor leave room to overflow with jitter
+const maxBackoffDelay = time.Duration(math.MaxInt64 / 2)
+
+// ConstantBackoff waits the same delay before every retry
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// NextDelay returns the constant delay
+func (b ConstantBackoff) NextDelay(attempt int) time.Duration {
+	return b.Delay
+}
+
+// LinearBackoff increases the delay by Step before every retry, capped at Max if set
+type LinearBackoff struct {
+	Initial time.Duration
+	Step    time.Duration
+	Max     time.Duration
+}
+
+// NextDelay returns the linearly increased delay
+func (b LinearBackoff) NextDelay(attempt int) time.Duration {
+	delay := maxBackoffDelay
+	if b.Step <= 0 || time.Duration(attempt) < (maxBackoffDelay-b.Initial)/b.Step {
+		delay = b.Initial + time.Duration(attempt)*b.Step
+	}
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+
+	return delay
+}
+
+// ExponentialBackoff multiplies the delay by Multiplier before every retry, capped at Max if set.
+// With Jitter, a random delay between zero and the computed one is used (full jitter).
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Multiplier float64
+	Max        time.Duration
+	Jitter     bool
+}
+
+// NextDelay returns the exponentially increased delay
+func (b ExponentialBackoff) NextDelay(attempt int) time.Duration {
+	// Load default multiplier
+	multiplier := b.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	// Compute delay without overflowing
+	if b.Initial <= 0 {
+		return 0
+	}
+	delay := maxBackoffDelay
+	if computed := float64(b.Initial) * math.Pow(multiplier, float64(attempt)); computed < float64(maxBackoffDelay) {
+		delay = time.Duration(computed)
+	}
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+
+	// Apply full jitter
+	if b.Jitter && delay > 0 {
+		return time.Duration(rand.Int64N(int64(delay) + 1))
+	}
+
+	return delay
+}
+
+// WithBackoff sets backoff strategy for request, replacing WithRetryDelay and WithExponentialBackoff
+func WithBackoff(backoff BackoffStrategy) Option {
+	return func(c *Client) {
+		c.backoff = backoff
+	}
+}
+
+// backoffStrategy returns the configured backoff strategy or the one built from retry delay
+func (c *Client) backoffStrategy() BackoffStrategy {
+	if c.backoff != nil {
+		return c.backoff
+	}
+
+	delay := time.Duration(c.config.RetryDelay) * time.Second
+	if c.exponentialBackoff {
+		return ExponentialBackoff{
+			Initial:    delay,
+			Multiplier: 2,
+		}
+	}
+
+	return ConstantBackoff{
+		Delay: delay,
+	}
+}
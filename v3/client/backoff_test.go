@@ -0,0 +1,100 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	b := ConstantBackoff{Delay: time.Second}
+	for _, attempt := range []int{0, 1, 10, 1000} {
+		if delay := b.NextDelay(attempt); delay != time.Second {
+			t.Errorf("NextDelay(%d) = %v, want %v", attempt, delay, time.Second)
+		}
+	}
+}
+
+func TestLinearBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff LinearBackoff
+		attempt int
+		want    time.Duration
+	}{
+		{"first", LinearBackoff{Initial: time.Second, Step: time.Second}, 0, time.Second},
+		{"stepped", LinearBackoff{Initial: time.Second, Step: time.Second}, 3, 4 * time.Second},
+		{"capped", LinearBackoff{Initial: time.Second, Step: time.Second, Max: 2 * time.Second}, 3, 2 * time.Second},
+		{"no overflow", LinearBackoff{Initial: time.Second, Step: time.Hour}, 1 << 40, maxBackoffDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if delay := tt.backoff.NextDelay(tt.attempt); delay != tt.want {
+				t.Errorf("NextDelay(%d) = %v, want %v", tt.attempt, delay, tt.want)
+			}
+		})
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff ExponentialBackoff
+		attempt int
+		want    time.Duration
+	}{
+		{"first", ExponentialBackoff{Initial: time.Second}, 0, time.Second},
+		{"default multiplier", ExponentialBackoff{Initial: time.Second}, 3, 8 * time.Second},
+		{"multiplier", ExponentialBackoff{Initial: time.Second, Multiplier: 3}, 2, 9 * time.Second},
+		{"capped", ExponentialBackoff{Initial: time.Second, Max: 5 * time.Second}, 10, 5 * time.Second},
+		{"no overflow", ExponentialBackoff{Initial: time.Second}, 40, maxBackoffDelay},
+		{"no initial", ExponentialBackoff{}, 5000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if delay := tt.backoff.NextDelay(tt.attempt); delay != tt.want {
+				t.Errorf("NextDelay(%d) = %v, want %v", tt.attempt, delay, tt.want)
+			}
+		})
+	}
+}
+
+func TestExponentialBackoffJitter(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff ExponentialBackoff
+		attempt int
+		max     time.Duration
+	}{
+		{"uncapped", ExponentialBackoff{Initial: time.Second, Jitter: true}, 3, 8 * time.Second},
+		{"capped", ExponentialBackoff{Initial: time.Second, Max: 5 * time.Second, Jitter: true}, 10, 5 * time.Second},
+		{"no overflow", ExponentialBackoff{Initial: time.Second, Jitter: true}, 40, maxBackoffDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				if delay := tt.backoff.NextDelay(tt.attempt); delay < 0 || delay > tt.max {
+					t.Fatalf("NextDelay(%d) = %v, want within [0, %v]", tt.attempt, delay, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffStrategy(t *testing.T) {
+	tests := []struct {
+		name   string
+		client *Client
+		want   BackoffStrategy
+	}{
+		{"constant", &Client{config: ClientConfig{RetryDelay: 2}}, ConstantBackoff{Delay: 2 * time.Second}},
+		{"exponential", &Client{config: ClientConfig{RetryDelay: 1}, exponentialBackoff: true}, ExponentialBackoff{Initial: time.Second, Multiplier: 2}},
+		{"custom", &Client{config: ClientConfig{RetryDelay: 1}, backoff: LinearBackoff{Step: time.Second}}, LinearBackoff{Step: time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.client.backoffStrategy(); got != tt.want {
+				t.Errorf("backoffStrategy() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// CallOption provides a basic option type for a single call
+type CallOption func(*callOptions)
+
+// callOptions provides per-call settings consumed by Send
+type callOptions struct {
+	ctx            context.Context
+	header         http.Header
+	query          url.Values
+	idempotencyKey string
+	contentType    string
+	codec          Codec
+	noRedirects    bool
+	headersOnly    bool
+	noRetry        bool
+	uploadProgress func(sent int64, total int64)
+	timeout        time.Duration
+	cancel         context.CancelFunc
+}
+
+// WithContext sets context for the call
+func WithContext(ctx context.Context) CallOption {
+	return func(o *callOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithHeader adds an extra header to the call
+func WithHeader(key string, value string) CallOption {
+	return func(o *callOptions) {
+		o.header.Add(key, value)
+	}
+}
+
+// WithQuery adds query params to the call, merged with those already in the URL, so a call
+// can carry both query params and a payload
+func WithQuery(query url.Values) CallOption {
+	return func(o *callOptions) {
+		for key, values := range query {
+			for _, value := range values {
+				o.query.Add(key, value)
+			}
+		}
+	}
+}
+
+// WithIdempotencyKey sets idempotency key for the call
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// WithContentType sets content type of the payload, selecting the codec registered for it
+func WithContentType(contentType string) CallOption {
+	return func(o *callOptions) {
+		o.contentType = contentType
+	}
+}
+
+// WithCallCodec sets codec for the call, used to marshal the payload and unmarshal the response.
+// It takes precedence over codecs set by WithCodec, WithMarshal and WithUnmarshal.
+func WithCallCodec(codec Codec) CallOption {
+	return func(o *callOptions) {
+		o.codec = codec
+	}
+}
+
+// WithCallTimeout bounds the call with a deadline of d, including retries and token refresh,
+// on top of the deadline of the call context and the client-wide timeout per attempt
+func WithCallTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithHeadersOnly makes the call succeed as soon as headers arrive with an accepted status,
+// closing the body unread, e.g. for liveness probes with Ping. The result has no data.
+func WithHeadersOnly() CallOption {
+	return func(o *callOptions) {
+		o.headersOnly = true
+	}
+}
+
+// WithNoRetry sends the call once, without retries by the client or RetryTransport, for
+// operations that must not be repeated. An idempotency key makes retries safe where upstream
+// deduplicates by it, so it is the alternative when retrying is still wanted.
+func WithNoRetry() CallOption {
+	return func(o *callOptions) {
+		o.noRetry = true
+	}
+}
+
+// noRetryKey is the context key of calls sent once
+type noRetryKey struct{}
+
+// retriesAllowed reports whether the call of ctx may be retried
+func retriesAllowed(ctx context.Context) bool {
+	noRetry, _ := ctx.Value(noRetryKey{}).(bool)
+	return !noRetry
+}
+
+// WithCallFollowRedirects sets whether redirects are followed for the call, it can only
+// disable following when the client follows redirects
+func WithCallFollowRedirects(followRedirects bool) CallOption {
+	return func(o *callOptions) {
+		o.noRedirects = !followRedirects
+	}
+}
+
+// noRedirectsKey is the context key of calls not following redirects
+type noRedirectsKey struct{}
+
+// redirectsFollowed reports whether the call of ctx follows redirects
+func redirectsFollowed(ctx context.Context) bool {
+	noRedirects, _ := ctx.Value(noRedirectsKey{}).(bool)
+	return !noRedirects
+}
+
+// newCallOptions builds per-call settings from options
+func newCallOptions(base context.Context, options []CallOption) *callOptions {
+	// Load default call options
+	o := &callOptions{
+		ctx:         context.Background(),
+		header:      make(http.Header),
+		query:       make(url.Values),
+		contentType: ContentTypeJSON,
+	}
+
+	// Load options
+	for _, f := range options {
+		f(o)
+	}
+
+	// Fall back to background context
+	if o.ctx == nil {
+		o.ctx = context.Background()
+	}
+
+	// Cancel call with base context
+	o.cancel = func() {}
+	if base.Done() != nil {
+		ctx, cancel := context.WithCancelCause(o.ctx)
+		stop := context.AfterFunc(base, func() {
+			cancel(context.Cause(base))
+		})
+		o.ctx, o.cancel = ctx, func() {
+			stop()
+			cancel(nil)
+		}
+	}
+
+	// Bound call with its timeout
+	if o.timeout > 0 {
+		release := o.cancel
+		ctx, cancel := context.WithTimeout(o.ctx, o.timeout)
+		o.ctx, o.cancel = ctx, func() {
+			cancel()
+			release()
+		}
+	}
+
+	// Mark call sent once
+	if o.noRetry {
+		o.ctx = context.WithValue(o.ctx, noRetryKey{}, true)
+	}
+
+	// Mark call not following redirects
+	if o.noRedirects {
+		o.ctx = context.WithValue(o.ctx, noRedirectsKey{}, true)
+	}
+
+	return o
+}
+
+// sleep waits for the duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// transcode decodes a response body of a charset other than UTF-8 in Content-Type to UTF-8,
+// e.g. GBK, bodies without charset are taken as UTF-8
+func transcode(res *http.Response) error {
+	_, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		return nil // No parsable charset
+	}
+	charset := strings.ToLower(strings.TrimSpace(params["charset"]))
+	if charset == "" || charset == "utf-8" || charset == "utf8" || charset == "us-ascii" {
+		return nil
+	}
+
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return fmt.Errorf("unsupported response charset %q: %w", charset, err)
+	}
+
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{transform.NewReader(res.Body, encoding.NewDecoder()), res.Body}
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+
+	return nil
+}
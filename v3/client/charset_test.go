@@ -0,0 +1,55 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func TestResponseCharset(t *testing.T) {
+	const name = "张三"
+	tests := []struct {
+		name        string
+		contentType string
+		encode      func(string) string
+		wantErr     bool
+	}{
+		{"gbk", ContentTypeJSON + "; charset=GBK", func(s string) string {
+			encoded, _ := simplifiedchinese.GBK.NewEncoder().String(s)
+			return encoded
+		}, false},
+		{"utf-8", ContentTypeJSON + "; charset=utf-8", func(s string) string { return s }, false},
+		{"unspecified", ContentTypeJSON, func(s string) string { return s }, false},
+		{"unsupported", ContentTypeJSON + "; charset=x-unknown", func(s string) string { return s }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = fmt.Fprint(w, tt.encode(fmt.Sprintf(
+					`{"code":%d,"msg":"实名认证","data":{"name":%q}}`, CodeSuccess, name,
+				)))
+			})
+			c := newTestClient(t, server)
+
+			result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+			if tt.wantErr {
+				if result.Err == nil {
+					t.Error("Send() error = nil, want unsupported charset")
+				}
+				return
+			}
+			var data struct {
+				Name string `json:"name"`
+			}
+			if err := result.DecodeInto(&data); err != nil {
+				t.Fatalf("DecodeInto() error = %v", err)
+			}
+			if result.Msg != "实名认证" || data.Name != name {
+				t.Errorf("Msg = %q, name = %q, want 实名认证 and %s", result.Msg, data.Name, name)
+			}
+		})
+	}
+}
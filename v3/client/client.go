@@ -1,30 +1,87 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"go.gh.ink/openapi/sdk/20260422/v3"
 )
 
-// Client provides basic struct for client object
+// Client provides basic struct for client object.
+// A Client is safe for concurrent use by multiple goroutines and service packages:
+// requests share one transport, token refresh is serialised by the refresh mutex,
+// and every attempt sends its own copy of the request. Options and Logger must not
+// be changed once the client is in use.
 type Client struct {
-	endpoint           string
-	secretID           string
-	secretKey          string
-	enableToken        bool
-	token              string
-	timeout            int
-	maxRetries         int
-	retryDelay         int
-	exponentialBackoff bool
-	marshal            func(any) ([]byte, error)
-	unmarshal          func([]byte, any) error
-	Logger             Logger
+	config                ClientConfig
+	httpClient            *http.Client
+	closed                chan struct{}
+	closeOnce             *sync.Once
+	baseCtx               context.Context
+	recorder              *recorder
+	clientTrace           bool
+	shortDomain           string
+	secretProvider        SecretProvider
+	autoRefresh           bool
+	refreshAhead          time.Duration
+	refreshJitter         time.Duration
+	auth                  *tokenState
+	expirySkew            time.Duration
+	clockSkew             time.Duration
+	exponentialBackoff    bool
+	backoff               BackoffStrategy
+	retryHintHeader       string
+	maxRetryWait          time.Duration
+	authenticator         func(*http.Request)
+	tokenScheme           string
+	keyScheme             string
+	errorMapper           func(*Result) error
+	dedup                 *dedupCache
+	observer              Observer
+	envelope              envelopeFields
+	quotaCodes            map[int]bool
+	acceptStatus          map[int]bool
+	contextHeaders        []contextHeader
+	managedHeaderOverride bool
+	host                  string
+	bufferPool            bool
+	marshal               func(any) ([]byte, error)
+	unmarshal             func([]byte, any) error
+	strictDecoding        bool
+	codecs                map[string]Codec
+	fieldNameMapper       func(string) string
+	validators            map[string]ResponseValidator
+	logPolicy             LogPolicy
+	logRequestBody        bool
+	maxLogBodyBytes       int
+	optionErr             error
+	codeLogLevel          map[int]Level
+	Logger                Logger
 }
 
+var (
+	// ErrMissingCredentials is returned when SecretID or SecretKey is empty
+	ErrMissingCredentials = errors.New("secretID and secretKey must not be empty")
+
+	// ErrClientClosed is returned when sending with a closed client
+	ErrClientClosed = errors.New("client is closed")
+
+	// ErrInvalidEndpoint is returned when creating a client with an invalid endpoint
+	ErrInvalidEndpoint = errors.New("invalid endpoint")
+
+	// ErrInvalidShortDomain is returned when creating a client with an invalid short domain
+	ErrInvalidShortDomain = errors.New("invalid short domain")
+)
+
 // Option provides a basic option type
 type Option func(*Client)
 
@@ -38,11 +95,55 @@ func WithLogger(logger Logger) Option {
 // WithEndpoint sets default endpoint
 func WithEndpoint(endpoint string) Option {
 	return func(c *Client) {
-		c.endpoint = endpoint
+		normalized, err := normalizeEndpoint(endpoint)
+		if err != nil {
+			c.optionErr = err
+			return
+		}
+		c.config.Endpoint = normalized
 	}
 }
 
-// WithMarshal sets default marshal lib
+// normalizeEndpoint checks the endpoint is an absolute http or https URL and strips trailing slashes
+func normalizeEndpoint(endpoint string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("%w: %q must be an absolute http or https URL", ErrInvalidEndpoint, endpoint)
+	}
+
+	return strings.TrimRight(parsed.String(), "/"), nil
+}
+
+// WithShortDomain sets domain of public short links, such as a branded domain of a white-label
+// customer, optionally with a path prefix, e.g. "go.example.com/s"
+func WithShortDomain(shortDomain string) Option {
+	return func(c *Client) {
+		normalized, err := normalizeShortDomain(shortDomain)
+		if err != nil {
+			c.optionErr = err
+			return
+		}
+		c.shortDomain = normalized
+	}
+}
+
+// normalizeShortDomain checks the short domain is a host with an optional path prefix and strips trailing slashes
+func normalizeShortDomain(shortDomain string) (string, error) {
+	trimmed := strings.TrimRight(strings.TrimSpace(shortDomain), "/")
+	parsed, err := url.Parse("https://" + trimmed)
+	if err != nil || parsed.Host == "" || strings.Contains(trimmed, "://") || strings.ContainsAny(trimmed, "?#@ ") {
+		return "", fmt.Errorf("%w: %q must be a host with an optional path prefix", ErrInvalidShortDomain, shortDomain)
+	}
+
+	return trimmed, nil
+}
+
+// ShortURL returns the public URL of a short link, https://<short domain>/<linkID>
+func (c *Client) ShortURL(linkID string) string {
+	return joinURL("https://"+c.shortDomain, url.PathEscape(linkID))
+}
+
+// WithMarshal sets default marshal lib, nil restores the default JSON codec
 func WithMarshal(marshal func(any) ([]byte, error)) Option {
 	return func(c *Client) {
 		c.marshal = marshal
@@ -56,24 +157,146 @@ func WithUnmarshal(unmarshal func([]byte, any) error) Option {
 	}
 }
 
+// WithEnvelopeFields sets field names of code, msg and data in the response envelope
+func WithEnvelopeFields(code string, msg string, data string) Option {
+	return func(c *Client) {
+		c.envelope = envelopeFields{
+			code: code,
+			msg:  msg,
+			data: data,
+		}
+	}
+}
+
+// WithQuotaCodes sets API codes reported as QuotaError besides CodeQuotaExceeded, which always is
+func WithQuotaCodes(codes ...int) Option {
+	return func(c *Client) {
+		c.quotaCodes = map[int]bool{
+			CodeQuotaExceeded: true,
+		}
+		for _, code := range codes {
+			c.quotaCodes[code] = true
+		}
+	}
+}
+
+// WithAcceptStatus sets HTTP status codes accepted besides 200, an empty body of them
+// is treated as success without data
+func WithAcceptStatus(statusCodes ...int) Option {
+	return func(c *Client) {
+		for _, statusCode := range statusCodes {
+			c.acceptStatus[statusCode] = true
+		}
+	}
+}
+
+// WithDefaultHeaders sets headers sent with every request including token refresh, which
+// per-call headers replace, while Authorization and User-Agent are managed by the SDK
+// unless WithManagedHeaderOverride allows them
+func WithDefaultHeaders(header http.Header) Option {
+	return func(c *Client) {
+		c.config.DefaultHeaders = header.Clone()
+	}
+}
+
+// WithHeaderFromContext sets a header sent with the value of ctxKey in the call context when present,
+// e.g. a correlation ID, replacing default headers and replaced by call headers
+func WithHeaderFromContext(headerName string, ctxKey any) Option {
+	return func(c *Client) {
+		c.contextHeaders = append(c.contextHeaders, contextHeader{
+			name: headerName,
+			key:  ctxKey,
+		})
+	}
+}
+
+// contextHeader provides a header sent with a context value
+type contextHeader struct {
+	name string
+	key  any
+}
+
+// WithManagedHeaderOverride sets whether Authorization and User-Agent set by default or call
+// headers are sent instead of the ones managed by the SDK
+func WithManagedHeaderOverride(managedHeaderOverride bool) Option {
+	return func(c *Client) {
+		c.managedHeaderOverride = managedHeaderOverride
+	}
+}
+
+// WithUserAgent sets the full User-Agent sent with every request, see openapi.BuildUserAgent
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.config.UserAgent = userAgent
+	}
+}
+
+// WithHost sets the Host header sent with every request including token refresh, e.g. for a virtual
+// host behind a load balancer or an IP endpoint. Connections and TLS verification still use the
+// host of the URL.
+func WithHost(host string) Option {
+	return func(c *Client) {
+		c.host = host
+	}
+}
+
+// WithBaseContext sets the context every call and background refresh derive from, cancelling
+// it aborts in-flight and future requests, e.g. on shutdown
+func WithBaseContext(ctx context.Context) Option {
+	return func(c *Client) {
+		if ctx != nil {
+			c.baseCtx = ctx
+		}
+	}
+}
+
 // WithTimeout sets timeout for request
 func WithTimeout(timeout int) Option {
 	return func(c *Client) {
-		c.timeout = timeout
+		c.config.Timeout = timeout
+	}
+}
+
+// WithMaxRequestBytes sets max size of marshalled payload, unlimited if not positive
+func WithMaxRequestBytes(maxRequestBytes int64) Option {
+	return func(c *Client) {
+		c.config.MaxRequestBytes = maxRequestBytes
+	}
+}
+
+// WithMaxResponseBytes sets max size of response body read, unlimited if not positive
+func WithMaxResponseBytes(maxResponseBytes int64) Option {
+	return func(c *Client) {
+		c.config.MaxResponseBytes = maxResponseBytes
+	}
+}
+
+// WithForceHTTP2 sets whether HTTP/2 is attempted, disabling it falls back to HTTP/1.1 only
+func WithForceHTTP2(forceHTTP2 bool) Option {
+	return func(c *Client) {
+		c.config.ForceHTTP2 = forceHTTP2
+	}
+}
+
+// WithFollowRedirects sets whether redirects are followed, when disabled the redirect
+// response is returned as the result with its Location in Result.Header
+func WithFollowRedirects(followRedirects bool) Option {
+	return func(c *Client) {
+		c.config.FollowRedirects = followRedirects
 	}
 }
 
 // WithMaxRetries sets max retries for request
 func WithMaxRetries(maxRetries int) Option {
 	return func(c *Client) {
-		c.maxRetries = maxRetries
+		c.config.MaxRetries = maxRetries
 	}
 }
 
 // WithRetryDelay sets retry delay for request
 func WithRetryDelay(retryDelay int) Option {
 	return func(c *Client) {
-		c.retryDelay = retryDelay
+		c.config.RetryDelay = retryDelay
 	}
 }
 
@@ -87,95 +310,374 @@ func WithExponentialBackoff(exponentialBackoff bool) Option {
 // EnableToken enables token as authorisation
 func EnableToken(enableToken bool) Option {
 	return func(c *Client) {
-		c.enableToken = enableToken
+		c.config.EnableToken = enableToken
+	}
+}
+
+// WithLazyToken defers getting token until the first request authorised by token
+func WithLazyToken(lazyToken bool) Option {
+	return func(c *Client) {
+		c.config.LazyToken = lazyToken
+	}
+}
+
+// WithTokenScheme sets the authorisation scheme of requests sent with token, Bearer in default
+func WithTokenScheme(tokenScheme string) Option {
+	return func(c *Client) {
+		c.tokenScheme = tokenScheme
+	}
+}
+
+// WithKeyScheme sets the authorisation scheme of requests sent with key, Basic in default
+func WithKeyScheme(keyScheme string) Option {
+	return func(c *Client) {
+		c.keyScheme = keyScheme
+	}
+}
+
+// WithAuthenticator sets a custom authorisation for requests sent with key,
+// which replaces SecretID and SecretKey and skips their validation
+func WithAuthenticator(authenticator func(*http.Request)) Option {
+	return func(c *Client) {
+		c.authenticator = authenticator
 	}
 }
 
 // GetEndpoint returns endpoint
 func (c *Client) GetEndpoint() string {
-	return c.endpoint
+	return c.config.Endpoint
+}
+
+// URL joins the endpoint and path parts with exactly one slash between each
+func (c *Client) URL(parts ...string) string {
+	return joinURL(append([]string{c.config.Endpoint}, parts...)...)
+}
+
+// joinURL joins URL parts with exactly one slash between each, skipping empty parts
+func joinURL(parts ...string) string {
+	var joined strings.Builder
+	for _, part := range parts {
+		if part == "" || part == "/" {
+			continue
+		}
+		if joined.Len() > 0 {
+			joined.WriteString("/")
+			part = strings.TrimLeft(part, "/")
+		}
+		joined.WriteString(strings.TrimRight(part, "/"))
+	}
+
+	return joined.String()
+}
+
+// Close releases idle connections and stops background work of the client,
+// the client is unusable after Close and requests fail with ErrClientClosed
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.httpClient.CloseIdleConnections()
+	})
+
+	return nil
+}
+
+// isClosed reports whether the client is closed
+func (c *Client) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// newHTTPClient builds the http client shared by all requests of the client
+func newHTTPClient(c *Client) *http.Client {
+	// Copy custom http client
+	var httpClient *http.Client
+	if c.httpClient != nil {
+		copied := *c.httpClient
+		httpClient = &copied
+	} else {
+		// Clone default transport
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+
+		// Configure protocols
+		transport.ForceAttemptHTTP2 = c.config.ForceHTTP2
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP1(true)
+		transport.Protocols.SetHTTP2(c.config.ForceHTTP2)
+		if !c.config.ForceHTTP2 && transport.TLSClientConfig != nil {
+			// Stop offering HTTP/2 inherited from a default transport already used
+			transport.TLSClientConfig.NextProtos = slices.DeleteFunc(
+				slices.Clone(transport.TLSClientConfig.NextProtos), func(proto string) bool {
+					return proto == "h2"
+				},
+			)
+		}
+
+		httpClient = &http.Client{
+			Transport: transport,
+			Timeout:   time.Duration(c.config.Timeout) * time.Second,
+		}
+	}
+
+	// Stop on redirects when not followed
+	followRedirects := c.config.FollowRedirects
+	checkRedirect := httpClient.CheckRedirect
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !followRedirects || !redirectsFollowed(req.Context()) {
+			return http.ErrUseLastResponse
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+
+	// Record or replay interactions
+	if c.recorder != nil {
+		c.recorder.base = httpClient.Transport
+		if c.recorder.base == nil {
+			c.recorder.base = http.DefaultTransport
+		}
+		httpClient.Transport = c.recorder
+	}
+
+	// Retry transport failures
+	if c.config.TransportRetries > 0 {
+		httpClient.Transport = &RetryTransport{
+			Base:       httpClient.Transport,
+			MaxRetries: c.config.TransportRetries,
+			Backoff:    c.backoffStrategy(),
+		}
+	}
+
+	return httpClient
 }
 
-// applyToken applies a new token
-func applyToken(c *Client) error {
+// applyToken applies a new token, must be called with the refresh mutex held
+func applyToken(ctx context.Context, c *Client) (err error) {
+	// Report refresh to observer
+	if c.observer != nil {
+		defer func() {
+			c.observer.TokenRefreshed(err)
+		}()
+	}
+
 	// Send request
 	result := c.Send(
-		strings.Join([]string{c.endpoint, "/openAPI/token"}, ""),
+		c.URL(TokenEndpoint),
 		http.MethodGet,
 		nil,
+		WithContext(ctx),
 	).WithKey()
 	if result.Err != nil {
-		c.Logger.Error(nil, fmt.Sprintf(
-			"failed to get token, sender error: %s", result.Err.Error(),
+		c.Logger.Error(ctx, fmt.Sprintf(
+			"failed to get token, sender error: status: %d, code: %d, %s", result.StatusCode, result.Code, result.Err.Error(),
 		))
-		return result.Err
+		return fmt.Errorf(
+			"failed to get token, sender error: status: %d, code: %d: %w", result.StatusCode, result.Code, result.Err,
+		)
 	}
 
 	// Check status code
 	if !result.OK() {
-		c.Logger.Error(nil, fmt.Sprintf(
-			"failed to get token, upstream failed: code: %d, msg: %s", result.Code, result.Msg,
+		c.logCode(ctx, result.Code, LevelError, fmt.Sprintf(
+			"failed to get token, upstream failed: status: %d, code: %d, msg: %s", result.StatusCode, result.Code, result.Msg,
 		))
-		return fmt.Errorf("failed to get token, upstream failed: code: %d, msg: %s", result.Code, result.Msg)
+		return fmt.Errorf(
+			"failed to get token, upstream failed: status: %d, code: %d, msg: %s", result.StatusCode, result.Code, result.Msg,
+		)
 	}
 
 	// Build token struct
 	var token struct {
-		Token string `json:"token"`
+		Token  string          `json:"token"`
+		Expiry int64           `json:"expiry"`
+		Scopes json.RawMessage `json:"scopes"`
 	}
 
 	// Unmarshal token data
-	if err := result.Unmarshal(&token); err != nil {
-		c.Logger.Error(nil, fmt.Sprintf(
-			"failed to get token, unmarshal error: %s", result.Err.Error(),
+	if err := result.DecodeLenient(&token); err != nil {
+		c.Logger.Error(ctx, fmt.Sprintf(
+			"failed to get token, unmarshal error: status: %d, code: %d, %s", result.StatusCode, result.Code, err.Error(),
 		))
-		return err
+		return fmt.Errorf(
+			"failed to get token, unmarshal error: status: %d, code: %d: %w", result.StatusCode, result.Code, err,
+		)
+	}
+
+	// Check token data
+	if token.Token == "" {
+		c.Logger.Error(ctx, fmt.Sprintf(
+			"failed to get token, empty token: status: %d, code: %d, data: %s", result.StatusCode, result.Code, c.logBody(result.Body),
+		))
+		return fmt.Errorf(
+			"failed to get token, empty token: status: %d, code: %d", result.StatusCode, result.Code,
+		)
 	}
 
 	// Save token
-	c.token = token.Token
+	c.auth.token = token.Token
+	c.auth.expiry = time.Time{}
+	if token.Expiry > 0 {
+		c.auth.expiry = time.Unix(token.Expiry, 0)
+	}
+	c.auth.offset = serverOffset(result.Header)
+	c.auth.jitter = c.drawJitter()
+	c.auth.scopes = parseScopes(token.Scopes)
+	c.Logger.Debug(ctx, fmt.Sprintf(
+		"got token %s, expiry %s, server clock offset %s", maskToken(token.Token), c.auth.expiry, c.auth.offset,
+	))
 	return nil
 }
 
 // NewClient creates a new client to use service of Ghink Open API
 func NewClient(secretID string, secretKey string, options ...Option) (*Client, error) {
+	return NewClientContext(context.Background(), secretID, secretKey, options...)
+}
+
+// NewClientContext creates a new client, bounding the initial token request by ctx
+func NewClientContext(ctx context.Context, secretID string, secretKey string, options ...Option) (*Client, error) {
+	cfg := DefaultConfig()
+	cfg.SecretID = secretID
+	cfg.SecretKey = secretKey
+
+	return newClient(ctx, cfg, options)
+}
+
+// newClient creates a new client from cfg, options are applied after it, so they override it
+func newClient(ctx context.Context, cfg ClientConfig, options []Option) (*Client, error) {
 	// Create client
 	client := new(Client)
+	client.config = cfg
+	client.config.DefaultHeaders = cfg.DefaultHeaders.Clone()
 
 	// Load default logger
 	client.Logger = NewLogger()
 
-	// Load default endpoint
-	client.endpoint = openapi.Endpoint
+	// Load default short domain
+	client.shortDomain = openapi.ShortDomain
+
+	// Accept 200 in default
+	client.acceptStatus = map[int]bool{
+		http.StatusOK: true,
+	}
+
+	// Load default quota codes
+	client.quotaCodes = map[int]bool{
+		CodeQuotaExceeded: true,
+	}
+
+	// Load default unmarshal lib, payloads are marshalled by the default JSON codec
+	client.unmarshal = unmarshalJSON
+
+	// Load default base context
+	client.baseCtx = context.Background()
 
-	// Load default marshal and unmarshal lib
-	client.marshal = json.Marshal
-	client.unmarshal = json.Unmarshal
+	// Load default envelope fields
+	client.envelope = defaultEnvelope
 
-	// Load default maxRetries and retryDelay
-	client.timeout = 3
-	client.maxRetries = 5
-	client.retryDelay = 1
+	// Load default max log body size
+	client.maxLogBodyBytes = DefaultMaxLogBodyBytes
+
+	// Load default authorisation schemes
+	client.tokenScheme = "Bearer"
+	client.keyScheme = "Basic"
+
+	// Load default backoff
 	client.exponentialBackoff = true
+	client.retryHintHeader = DefaultRetryHintHeader
+	client.maxRetryWait = DefaultMaxRetryWait
+
+	// Load default token refresh
+	client.auth = newTokenState()
+	client.autoRefresh = true
+	client.refreshAhead = time.Minute
+	client.refreshJitter = 30 * time.Second
 
-	// Enable token in default
-	client.enableToken = true
+	// Load default expirySkew and clockSkew
+	client.expirySkew = 30 * time.Second
+	client.clockSkew = 5 * time.Second
 
 	// Load options
 	for _, f := range options {
 		f(client)
 	}
+	if client.optionErr != nil {
+		client.Logger.Error(ctx, client.optionErr.Error())
+		return nil, client.optionErr
+	}
+
+	// Load secret key file
+	if client.config.SecretKeyFile != "" {
+		client.secretProvider = fileSecretProvider(client.config.SecretKeyFile)
+	}
 
-	// Save keys
-	client.secretID = secretID
-	client.secretKey = secretKey
+	// Build shared http client
+	client.httpClient = newHTTPClient(client)
+	client.closed = make(chan struct{})
+	client.closeOnce = new(sync.Once)
+
+	// Check credentials
+	if client.authenticator == nil && client.secretProvider == nil && (client.config.SecretID == "" || client.config.SecretKey == "") {
+		client.Logger.Error(ctx, ErrMissingCredentials.Error())
+		return nil, ErrMissingCredentials
+	}
+	if client.authenticator == nil && client.secretProvider != nil {
+		if client.config.SecretID == "" {
+			client.Logger.Error(ctx, ErrMissingCredentials.Error())
+			return nil, ErrMissingCredentials
+		}
+		key, err := client.loadSecretKey(ctx)
+		if err != nil {
+			client.Logger.Error(ctx, fmt.Sprintf("failed to load secret key: %s", err.Error()))
+			return nil, fmt.Errorf("failed to load secret key: %w", err)
+		}
+		clear(key)
+	}
 
 	// Try to get token
-	if client.enableToken {
-		if err := applyToken(client); err != nil {
+	if client.config.EnableToken && !client.config.LazyToken {
+		if _, err := client.ensureToken(ctx); err != nil {
 			return nil, err
 		}
 	}
 
+	// Start background refresh
+	if client.config.EnableToken && client.config.BackgroundRefresh {
+		go client.refreshLoop()
+	}
+
 	return client, nil
 }
+
+// Environment variables read by NewClientFromEnv
+const (
+	EnvSecretID  = "GHINK_SECRET_ID"
+	EnvSecretKey = "GHINK_SECRET_KEY"
+	EnvEndpoint  = "GHINK_ENDPOINT"
+)
+
+// NewClientFromEnv creates a new client with SecretID and SecretKey read from
+// GHINK_SECRET_ID and GHINK_SECRET_KEY, and endpoint read from GHINK_ENDPOINT if set.
+// Options are applied after the environment, so WithEndpoint overrides GHINK_ENDPOINT.
+func NewClientFromEnv(options ...Option) (*Client, error) {
+	// Read keys
+	secretID := os.Getenv(EnvSecretID)
+	secretKey := os.Getenv(EnvSecretKey)
+	if secretID == "" || secretKey == "" {
+		return nil, fmt.Errorf("%w: %s and %s must be set", ErrMissingCredentials, EnvSecretID, EnvSecretKey)
+	}
+
+	// Read endpoint
+	if endpoint := os.Getenv(EnvEndpoint); endpoint != "" {
+		options = append([]Option{WithEndpoint(endpoint)}, options...)
+	}
+
+	return NewClient(secretID, secretKey, options...)
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go.gh.ink/openapi/sdk/20260422/v3"
+)
+
+func TestForceHTTP2(t *testing.T) {
+	tests := []struct {
+		name       string
+		forceHTTP2 bool
+		wantProto  string
+	}{
+		{"http2", true, "HTTP/2.0"},
+		{"http1", false, "HTTP/1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protos := make(chan string, 2)
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				protos <- r.Proto
+				if r.URL.Path == TokenEndpoint {
+					writeEnvelope(w, CodeSuccess, "ok", `{"token":"`+testToken+`"}`)
+					return
+				}
+				writeEnvelope(w, CodeSuccess, "ok", "null")
+			}))
+			server.EnableHTTP2 = true
+			server.StartTLS()
+			t.Cleanup(server.Close)
+
+			// Trust the server certificate before the first request
+			c := newTestClient(t, server, WithLazyToken(true), WithForceHTTP2(tt.forceHTTP2))
+			transport := c.httpClient.Transport.(*http.Transport)
+			transport.TLSClientConfig.RootCAs = server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+			if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken(); result.Err != nil {
+				t.Fatalf("Send() error = %v", result.Err)
+			}
+			for range 2 {
+				if proto := <-protos; proto != tt.wantProto {
+					t.Errorf("negotiated %s, want %s", proto, tt.wantProto)
+				}
+			}
+		})
+	}
+}
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []string
+		want  string
+	}{
+		{"no slash", []string{"https://api.test", "shortLink", "add"}, "https://api.test/shortLink/add"},
+		{"trailing slash", []string{"https://api.test/", "/shortLink/", "/add"}, "https://api.test/shortLink/add"},
+		{"path prefix", []string{"https://api.test/v3/", "/shortLink"}, "https://api.test/v3/shortLink"},
+		{"empty parts", []string{"https://api.test", "", "/", "add"}, "https://api.test/add"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinURL(tt.parts...); got != tt.want {
+				t.Errorf("joinURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		want     string
+		wantErr  bool
+	}{
+		{"https", "https://api.test", "https://api.test", false},
+		{"http", "http://api.test:8080", "http://api.test:8080", false},
+		{"trailing slash", "https://api.test/", "https://api.test", false},
+		{"path prefix", " https://api.test/v3// ", "https://api.test/v3", false},
+		{"no scheme", "api.test", "", true},
+		{"other scheme", "ftp://api.test", "", true},
+		{"no host", "https://", "", true},
+		{"garbage", "://%", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient("id", "key", WithEndpoint(tt.endpoint), WithLazyToken(true))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEndpoint) {
+					t.Errorf("NewClient() error = %v, want ErrInvalidEndpoint", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			t.Cleanup(func() {
+				_ = c.Close()
+			})
+			if got := c.GetEndpoint(); got != tt.want {
+				t.Errorf("GetEndpoint() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissingCredentials(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeEnvelope(w, CodeSuccess, "ok", fmt.Sprintf(`{"token":%q}`, testToken))
+	}))
+	t.Cleanup(server.Close)
+
+	for _, credentials := range [][2]string{{"", "key"}, {"id", ""}, {"", ""}} {
+		if _, err := NewClient(credentials[0], credentials[1], WithEndpoint(server.URL)); !errors.Is(err, ErrMissingCredentials) {
+			t.Errorf("NewClient(%q, %q) error = %v, want ErrMissingCredentials", credentials[0], credentials[1], err)
+		}
+	}
+
+	// A provider emptied after construction fails the call before sending
+	var key atomic.Value
+	key.Store("key")
+	c := newTestClient(t, server, WithLazyToken(true), WithSecretProvider(SecretProviderFunc(
+		func(context.Context) ([]byte, error) {
+			return []byte(key.Load().(string)), nil
+		},
+	)))
+	key.Store("")
+	if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithKey(); !errors.Is(result.Err, ErrMissingCredentials) {
+		t.Errorf("WithKey() error = %v, want ErrMissingCredentials", result.Err)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("got %d calls, want none", got)
+	}
+}
+
+func TestShortURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		shortDomain string
+		want        string
+		wantErr     bool
+	}{
+		{"default", "", "https://" + openapi.ShortDomain + "/a%2Fb", false},
+		{"custom", "go.example.com", "https://go.example.com/a%2Fb", false},
+		{"path prefix", "go.example.com/s/", "https://go.example.com/s/a%2Fb", false},
+		{"scheme", "https://go.example.com", "", true},
+		{"query", "go.example.com?a=b", "", true},
+		{"empty", " ", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := []Option{WithLazyToken(true)}
+			if tt.shortDomain != "" {
+				options = append(options, WithShortDomain(tt.shortDomain))
+			}
+			c, err := NewClient("id", "key", options...)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidShortDomain) {
+					t.Errorf("NewClient() error = %v, want ErrInvalidShortDomain", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			t.Cleanup(func() {
+				_ = c.Close()
+			})
+			if got := c.ShortURL("a/b"); got != tt.want {
+				t.Errorf("ShortURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Clone returns a copy of the client with options applied, e.g. a per-tenant logger or headers,
+// sharing the transport, the token and its refresh, and the closed state with the client, so
+// closing either closes both. Options of the transport, such as WithHTTPClient, WithForceHTTP2,
+// WithTransportRetries and WithFollowRedirects, and of credentials are not applied, while
+// WithTimeout is. Invalid options, such as a malformed endpoint, are logged and ignored.
+func (c *Client) Clone(options ...Option) *Client {
+	clone := *c
+
+	// Copy settings options update in place
+	clone.acceptStatus = maps.Clone(c.acceptStatus)
+	clone.quotaCodes = maps.Clone(c.quotaCodes)
+	clone.codecs = maps.Clone(c.codecs)
+	clone.config.DefaultHeaders = c.config.DefaultHeaders.Clone()
+	clone.contextHeaders = slices.Clip(c.contextHeaders)
+
+	// Load options
+	for _, f := range options {
+		f(&clone)
+	}
+
+	// Ignore invalid options
+	if clone.optionErr != nil {
+		clone.Logger.Error(nil, fmt.Sprintf("ignored invalid clone option: %s", clone.optionErr.Error()))
+		clone.config.Endpoint = c.config.Endpoint
+		clone.optionErr = nil
+	}
+
+	// Keep shared state
+	clone.httpClient = c.httpClient
+	clone.auth = c.auth
+	clone.closed = c.closed
+	clone.closeOnce = c.closeOnce
+	clone.config.SecretID = c.config.SecretID
+	clone.config.SecretKey = c.config.SecretKey
+	clone.config.SecretKeyFile = c.config.SecretKeyFile
+	clone.secretProvider = c.secretProvider
+	clone.authenticator = c.authenticator
+	clone.config.TransportRetries = c.config.TransportRetries
+	clone.config.ForceHTTP2 = c.config.ForceHTTP2
+	clone.config.FollowRedirects = c.config.FollowRedirects
+
+	// Apply timeout on the shared transport
+	if clone.config.Timeout != c.config.Timeout {
+		httpClient := *c.httpClient
+		httpClient.Timeout = time.Duration(clone.config.Timeout) * time.Second
+		clone.httpClient = &httpClient
+	}
+
+	return &clone
+}
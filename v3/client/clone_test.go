@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCloneSharesToken(t *testing.T) {
+	var authorization, tenant atomic.Value
+	server := newRotatingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		tenant.Store(r.Header.Get("X-Tenant"))
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	})
+	c := newTestClient(t, server)
+	clone := c.Clone(WithDefaultHeaders(http.Header{"X-Tenant": {"a"}}))
+
+	// Refresh through the clone, then call through the parent
+	if err := clone.RefreshToken(context.Background()); err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken(); result.Err != nil {
+		t.Fatalf("WithToken() error = %v", result.Err)
+	}
+	if got := authorization.Load(); got != "Bearer token-2" {
+		t.Errorf("parent Authorization = %v, want Bearer token-2", got)
+	}
+	if got := tenant.Load(); got != "" {
+		t.Errorf("parent X-Tenant = %v, want none", got)
+	}
+
+	// Overrides of the clone apply to its own calls only
+	if result := clone.Send(clone.URL("/data"), http.MethodGet, nil).WithToken(); result.Err != nil {
+		t.Fatalf("WithToken() error = %v", result.Err)
+	}
+	if got := tenant.Load(); got != "a" {
+		t.Errorf("clone X-Tenant = %v, want a", got)
+	}
+}
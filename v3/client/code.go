@@ -0,0 +1,28 @@
+package client
+
+// Known API codes of the response envelope
+const (
+	CodeSuccess       = 200
+	CodeUnauthorized  = 401
+	CodeForbidden     = 403
+	CodeNotFound      = 404
+	CodeQuotaExceeded = 429
+	CodeServerError   = 500
+	CodeTokenExpired  = 801
+)
+
+// codeMessages provides descriptions of known API codes
+var codeMessages = map[int]string{
+	CodeSuccess:       "success",
+	CodeUnauthorized:  "unauthorized",
+	CodeForbidden:     "forbidden",
+	CodeNotFound:      "not found",
+	CodeQuotaExceeded: "quota exceeded",
+	CodeServerError:   "server error",
+	CodeTokenExpired:  "token expired or permission denied",
+}
+
+// CodeMessage returns a description of the API code, or an empty string for unknown codes
+func CodeMessage(code int) string {
+	return codeMessages[code]
+}
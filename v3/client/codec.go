@@ -0,0 +1,252 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// ContentTypeJSON is the default content type of payloads and responses
+const ContentTypeJSON = "application/json"
+
+// Codec provides a basic interface for marshal and unmarshal lib of a content type
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// NewCodec builds a codec from marshal and unmarshal functions
+func NewCodec(marshal func(any) ([]byte, error), unmarshal func([]byte, any) error) Codec {
+	return funcCodec{
+		marshal:   marshal,
+		unmarshal: unmarshal,
+	}
+}
+
+// funcCodec is a codec backed by marshal and unmarshal functions
+type funcCodec struct {
+	marshal   func(any) ([]byte, error)
+	unmarshal func([]byte, any) error
+}
+
+// Marshal marshals v
+func (f funcCodec) Marshal(v any) ([]byte, error) {
+	return f.marshal(v)
+}
+
+// Unmarshal unmarshals data into v
+func (f funcCodec) Unmarshal(data []byte, v any) error {
+	return f.unmarshal(data, v)
+}
+
+// BufferCodec provides a basic interface for codecs able to marshal into a buffer, letting
+// WithBufferPool reuse buffers of payloads
+type BufferCodec interface {
+	Codec
+	MarshalTo(buf *bytes.Buffer, v any) error
+}
+
+// jsonCodec is the default codec, marshalling with encoding/json and unmarshalling with the
+// unmarshal lib
+type jsonCodec struct {
+	unmarshal func([]byte, any) error
+}
+
+// Marshal marshals v
+func (j jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// MarshalTo marshals v into buf as json.Marshal does
+func (j jsonCodec) MarshalTo(buf *bytes.Buffer, v any) error {
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+
+	// Drop newline written by the encoder
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// Unmarshal unmarshals data into v
+func (j jsonCodec) Unmarshal(data []byte, v any) error {
+	return j.unmarshal(data, v)
+}
+
+// WithCodec sets codec for a content type, used for payloads sent and responses received with it.
+// Codecs decoding the response envelope must support json.RawMessage fields.
+func WithCodec(contentType string, codec Codec) Option {
+	return func(c *Client) {
+		if c.codecs == nil {
+			c.codecs = make(map[string]Codec)
+		}
+		c.codecs[mediaType(contentType)] = codec
+	}
+}
+
+// ErrTrailingData is returned when a JSON body carries more than whitespace after its value, such as
+// concatenated objects or garbage
+var ErrTrailingData = errors.New("trailing data after JSON value")
+
+// WithStrictDecoding sets whether responses are decoded strictly by DecodeInto, failing on fields
+// unknown to the target, e.g. to catch contract drift in tests. Tokens and data of service packages
+// are still decoded leniently, see DecodeLenient. It replaces the unmarshal lib set by WithUnmarshal
+// with the default JSON decoding and does not affect codecs set by WithCodec.
+func WithStrictDecoding(strictDecoding bool) Option {
+	return func(c *Client) {
+		c.strictDecoding = strictDecoding
+		c.unmarshal = unmarshalJSON
+	}
+}
+
+// strictCodec returns codec disallowing unknown fields if it is the default JSON codec, possibly
+// behind a field name mapper, or codec itself otherwise
+func strictCodec(codec Codec) Codec {
+	switch codec := codec.(type) {
+	case jsonCodec:
+		return jsonCodec{unmarshal: unmarshalStrict}
+	case mapperCodec:
+		return mapperCodec{
+			codec:  strictCodec(codec.codec),
+			mapper: codec.mapper,
+		}
+	default:
+		return codec
+	}
+}
+
+// unmarshalJSON unmarshals exactly one JSON value of data into v, failing with ErrTrailingData
+// on anything but whitespace after it
+func unmarshalJSON(data []byte, v any) error {
+	return decodeJSON(data, v, false)
+}
+
+// unmarshalStrict unmarshals JSON data as unmarshalJSON does, also failing on fields unknown to v
+func unmarshalStrict(data []byte, v any) error {
+	return decodeJSON(data, v, true)
+}
+
+// decodeJSON decodes exactly one JSON value of data into v with a json.Decoder
+func decodeJSON(data []byte, v any, disallowUnknownFields bool) error {
+	// Leave empty data to json.Unmarshal for its error
+	if len(bytes.TrimSpace(data)) == 0 {
+		return json.Unmarshal(data, v)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+
+	// Check trailing data
+	if offset := decoder.InputOffset(); len(bytes.TrimSpace(data[offset:])) > 0 {
+		return fmt.Errorf("%w at offset %d", ErrTrailingData, offset)
+	}
+
+	return nil
+}
+
+// Codec returns the codec for a content type, falling back to marshal and unmarshal lib set by
+// WithMarshal and WithUnmarshal
+func (c *Client) Codec(contentType string) Codec {
+	codec, ok := c.codecs[mediaType(contentType)]
+	switch {
+	case ok:
+	case c.marshal == nil:
+		codec = jsonCodec{unmarshal: c.unmarshal}
+	default:
+		codec = NewCodec(c.marshal, c.unmarshal)
+	}
+
+	// Map field names
+	if c.fieldNameMapper != nil {
+		codec = mapperCodec{
+			codec:  codec,
+			mapper: c.fieldNameMapper,
+		}
+	}
+
+	return codec
+}
+
+// mediaType returns the lower-case media type of a content type without parameters
+func mediaType(contentType string) string {
+	if parsed, _, err := mime.ParseMediaType(contentType); err == nil {
+		return parsed
+	}
+
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// CodecError provides details of a codec failing in a way a codec should not, by panicking or
+// producing malformed data
+type CodecError struct {
+	Op    string
+	Codec string
+	Panic any
+	Err   error
+}
+
+// Error returns the error message
+func (e *CodecError) Error() string {
+	if e.Panic != nil {
+		return fmt.Sprintf("codec %s panicked on %s: %v", e.Codec, e.Op, e.Panic)
+	}
+
+	return fmt.Sprintf("codec %s failed on %s: %s", e.Codec, e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *CodecError) Unwrap() error {
+	return e.Err
+}
+
+// marshal marshals v with codec, converting a panic into a *CodecError
+func marshal(codec Codec, v any) (data []byte, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			data, err = nil, newCodecPanic("marshal", codec, p)
+		}
+	}()
+
+	return codec.Marshal(v)
+}
+
+// marshalTo marshals v into buf with codec, converting a panic into a *CodecError
+func marshalTo(codec BufferCodec, buf *bytes.Buffer, v any) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = newCodecPanic("marshal", codec, p)
+		}
+	}()
+
+	return codec.MarshalTo(buf, v)
+}
+
+// unmarshal unmarshals data into v with codec, converting a panic into a *CodecError
+func unmarshal(codec Codec, data []byte, v any) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = newCodecPanic("unmarshal", codec, p)
+		}
+	}()
+
+	return codec.Unmarshal(data, v)
+}
+
+// newCodecPanic builds the error of a codec panicking on op
+func newCodecPanic(op string, codec Codec, p any) *CodecError {
+	err, _ := p.(error)
+	return &CodecError{
+		Op:    op,
+		Codec: fmt.Sprintf("%T", codec),
+		Panic: p,
+		Err:   err,
+	}
+}
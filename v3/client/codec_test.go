@@ -0,0 +1,190 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUnmarshalJSONTrailingData(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		trailing bool
+	}{
+		{"clean", `{"a":1}`, false},
+		{"trailing whitespace", "{\"a\":1}\n\t ", false},
+		{"leading whitespace", " \n{\"a\":1}", false},
+		{"trailing garbage", `{"a":1} x`, true},
+		{"trailing brace", `{"a":1}}`, true},
+		{"concatenated", `{"a":1}{"a":2}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v map[string]int
+			err := unmarshalJSON([]byte(tt.data), &v)
+			if trailing := errors.Is(err, ErrTrailingData); trailing != tt.trailing {
+				t.Fatalf("unmarshalJSON() error = %v, want ErrTrailingData %v", err, tt.trailing)
+			}
+			if !tt.trailing && (err != nil || v["a"] != 1) {
+				t.Errorf("unmarshalJSON() = %v, %v, want map[a:1], nil", v, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONEmpty(t *testing.T) {
+	var v map[string]int
+	if err := unmarshalJSON(nil, &v); err == nil {
+		t.Error("unmarshalJSON() error = nil, want error")
+	}
+}
+
+func TestResponseDecodeFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		target error
+	}{
+		{"trailing garbage", `{"code":200,"msg":"ok","data":null} x`, ErrTrailingData},
+		{"concatenated", `{"code":200,"msg":"ok","data":null}{"code":200}`, ErrTrailingData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				_, _ = w.Write([]byte(tt.body))
+			})
+			c := newTestClient(t, server)
+
+			result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+			if !errors.Is(result.Err, tt.target) {
+				t.Errorf("error = %v, want %v", result.Err, tt.target)
+			}
+			var decodeErr *DecodeError
+			if !errors.As(result.Err, &decodeErr) {
+				t.Errorf("error = %v, want *DecodeError", result.Err)
+			}
+			if n := calls.Load(); n != 1 {
+				t.Errorf("upstream called %d times, want 1", n)
+			}
+		})
+	}
+}
+
+func TestCodecError(t *testing.T) {
+	tests := []struct {
+		name      string
+		codec     Codec
+		payload   any
+		wantOp    string
+		wantPanic bool
+		wantCalls int32
+	}{
+		{"marshal panic", NewCodec(func(any) ([]byte, error) {
+			panic("codec bug")
+		}, json.Unmarshal), map[string]string{}, "marshal", true, 0},
+		{"invalid JSON", NewCodec(func(any) ([]byte, error) {
+			return []byte("{"), nil
+		}, json.Unmarshal), map[string]string{}, "marshal", false, 0},
+		{"unmarshal panic", NewCodec(json.Marshal, func([]byte, any) error {
+			panic("codec bug")
+		}), nil, "unmarshal", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				writeEnvelope(w, CodeSuccess, "ok", "null")
+			})
+			c := newTestClient(t, server, WithLazyToken(true), WithCodec(ContentTypeJSON, tt.codec))
+
+			result := c.Send(c.URL("/data"), http.MethodPost, tt.payload).WithoutAuth()
+			var codecErr *CodecError
+			if !errors.As(result.Err, &codecErr) {
+				t.Fatalf("error = %v, want *CodecError", result.Err)
+			}
+			if codecErr.Op != tt.wantOp || (codecErr.Panic != nil) != tt.wantPanic {
+				t.Errorf("CodecError = %+v, want op %s and panic %v", codecErr, tt.wantOp, tt.wantPanic)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{"lenient", false, false},
+		{"strict", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				writeEnvelope(w, CodeSuccess, "ok", `{"id":"a","extra":1}`)
+			})
+			c := newTestClient(t, server, WithStrictDecoding(tt.strict))
+
+			result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+			if result.Err != nil {
+				t.Fatalf("Send() error = %v", result.Err)
+			}
+			var data struct {
+				ID string `json:"id"`
+			}
+			err := result.DecodeInto(&data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeInto() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && data.ID != "a" {
+				t.Errorf("ID = %q, want a", data.ID)
+			}
+		})
+	}
+}
+
+func TestStrictDecodingInternal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == TokenEndpoint {
+			writeEnvelope(w, CodeSuccess, "ok", `{"token":"test-token","expiry":0,"type":"Bearer"}`)
+			return
+		}
+		writeEnvelope(w, CodeSuccess, "ok", `{"ok":true,"extra":1}`)
+	}))
+	t.Cleanup(server.Close)
+
+	// Fetch token with a field unknown to the client
+	c := newTestClient(t, server, WithStrictDecoding(true))
+
+	result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+	if result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+
+	// Decode ok of data with unknown fields
+	ok, err := result.DecodeOK()
+	if err != nil || !ok {
+		t.Errorf("DecodeOK() = %v, %v, want true, nil", ok, err)
+	}
+
+	// Decode user targets strictly only
+	var data struct {
+		Ok bool `json:"ok"`
+	}
+	if err = result.DecodeInto(&data); err == nil {
+		t.Error("DecodeInto() error = nil, want unknown field")
+	}
+	if err = result.DecodeLenient(&data); err != nil || !data.Ok {
+		t.Errorf("DecodeLenient() = %v, %v, want true, nil", data.Ok, err)
+	}
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.gh.ink/openapi/sdk/20260422/v3"
+)
+
+// ErrInvalidConfig is matched by the errors of ClientConfig.Validate
+var ErrInvalidConfig = errors.New("invalid client config")
+
+// ClientConfig holds the client settings that can be serialised, e.g. decoded from JSON or YAML.
+// The options of these settings write into the config of the client, so a config and options
+// always describe the same client.
+type ClientConfig struct {
+	SecretID          string      `json:"secretID" yaml:"secretID"`
+	SecretKey         string      `json:"secretKey" yaml:"secretKey"`
+	SecretKeyFile     string      `json:"secretKeyFile" yaml:"secretKeyFile"`
+	Endpoint          string      `json:"endpoint" yaml:"endpoint"`
+	UserAgent         string      `json:"userAgent" yaml:"userAgent"`
+	DefaultHeaders    http.Header `json:"defaultHeaders" yaml:"defaultHeaders"`
+	Timeout           int         `json:"timeout" yaml:"timeout"`
+	MaxRetries        int         `json:"maxRetries" yaml:"maxRetries"`
+	RetryDelay        int         `json:"retryDelay" yaml:"retryDelay"`
+	TransportRetries  int         `json:"transportRetries" yaml:"transportRetries"`
+	MaxRequestBytes   int64       `json:"maxRequestBytes" yaml:"maxRequestBytes"`
+	MaxResponseBytes  int64       `json:"maxResponseBytes" yaml:"maxResponseBytes"`
+	ForceHTTP2        bool        `json:"forceHTTP2" yaml:"forceHTTP2"`
+	FollowRedirects   bool        `json:"followRedirects" yaml:"followRedirects"`
+	EnableToken       bool        `json:"enableToken" yaml:"enableToken"`
+	LazyToken         bool        `json:"lazyToken" yaml:"lazyToken"`
+	BackgroundRefresh bool        `json:"backgroundRefresh" yaml:"backgroundRefresh"`
+}
+
+// DefaultConfig returns the config of a client created without options. Start from it and
+// decode JSON or YAML over it, so fields missing from the document keep their defaults.
+func DefaultConfig() ClientConfig {
+	return ClientConfig{
+		Endpoint:        openapi.Endpoint,
+		UserAgent:       openapi.UserAgent,
+		Timeout:         3,
+		MaxRetries:      5,
+		RetryDelay:      1,
+		ForceHTTP2:      true,
+		FollowRedirects: true,
+		EnableToken:     true,
+	}
+}
+
+// Validate checks the config, reporting every invalid field in an error matching ErrInvalidConfig
+func (cfg ClientConfig) Validate() error {
+	var errs []error
+
+	// Check credentials
+	if cfg.SecretID == "" {
+		errs = append(errs, errors.New("secretID must not be empty"))
+	}
+	switch {
+	case cfg.SecretKey == "" && cfg.SecretKeyFile == "":
+		errs = append(errs, errors.New("one of secretKey and secretKeyFile must be set"))
+	case cfg.SecretKey != "" && cfg.SecretKeyFile != "":
+		errs = append(errs, errors.New("only one of secretKey and secretKeyFile may be set"))
+	}
+
+	// Check endpoint
+	if cfg.Endpoint == "" {
+		errs = append(errs, errors.New("endpoint must not be empty"))
+	} else if _, err := normalizeEndpoint(cfg.Endpoint); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Check numbers
+	if cfg.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("timeout must be positive: %d", cfg.Timeout))
+	}
+	if cfg.MaxRetries < 1 {
+		errs = append(errs, fmt.Errorf("maxRetries must be at least 1: %d", cfg.MaxRetries))
+	}
+	for _, field := range []struct {
+		name  string
+		value int64
+	}{
+		{"retryDelay", int64(cfg.RetryDelay)},
+		{"transportRetries", int64(cfg.TransportRetries)},
+		{"maxRequestBytes", cfg.MaxRequestBytes},
+		{"maxResponseBytes", cfg.MaxResponseBytes},
+	} {
+		if field.value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative: %d", field.name, field.value))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// NewClientFromConfig creates a new client from a validated config, options are applied
+// after the config, so they override it
+func NewClientFromConfig(cfg ClientConfig, options ...Option) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Normalize endpoint as WithEndpoint does
+	endpoint, err := normalizeEndpoint(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Endpoint = endpoint
+
+	return newClient(context.Background(), cfg, options)
+}
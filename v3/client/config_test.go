@@ -0,0 +1,143 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := func() ClientConfig {
+		cfg := DefaultConfig()
+		cfg.SecretID = "id"
+		cfg.SecretKey = "key"
+		return cfg
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*ClientConfig)
+		want   []string
+	}{
+		{"valid", func(*ClientConfig) {}, nil},
+		{"key file", func(cfg *ClientConfig) { cfg.SecretKey, cfg.SecretKeyFile = "", "key.txt" }, nil},
+		{"missing id", func(cfg *ClientConfig) { cfg.SecretID = "" }, []string{"secretID"}},
+		{"missing key", func(cfg *ClientConfig) { cfg.SecretKey = "" }, []string{"one of secretKey and secretKeyFile"}},
+		{"key and key file", func(cfg *ClientConfig) { cfg.SecretKeyFile = "key.txt" }, []string{"only one of"}},
+		{"missing endpoint", func(cfg *ClientConfig) { cfg.Endpoint = "" }, []string{"endpoint must not be empty"}},
+		{"invalid endpoint", func(cfg *ClientConfig) { cfg.Endpoint = "example.com" }, []string{"must be an absolute http or https URL"}},
+		{"zero timeout", func(cfg *ClientConfig) { cfg.Timeout = 0 }, []string{"timeout must be positive"}},
+		{"negative timeout", func(cfg *ClientConfig) { cfg.Timeout = -1 }, []string{"timeout must be positive"}},
+		{"zero retries", func(cfg *ClientConfig) { cfg.MaxRetries = 0 }, []string{"maxRetries must be at least 1"}},
+		{"negative retries", func(cfg *ClientConfig) { cfg.MaxRetries = -2 }, []string{"maxRetries must be at least 1"}},
+		{"negative retry delay", func(cfg *ClientConfig) { cfg.RetryDelay = -1 }, []string{"retryDelay must not be negative"}},
+		{"negative transport retries", func(cfg *ClientConfig) { cfg.TransportRetries = -1 }, []string{"transportRetries must not be negative"}},
+		{"negative sizes", func(cfg *ClientConfig) { cfg.MaxRequestBytes, cfg.MaxResponseBytes = -1, -1 }, []string{"maxRequestBytes", "maxResponseBytes"}},
+		{"every field", func(cfg *ClientConfig) { *cfg = ClientConfig{} }, []string{"secretID", "secretKey", "endpoint", "timeout", "maxRetries"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("Validate() error = %v, want ErrInvalidConfig", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	cfg := DefaultConfig()
+	cfg.SecretID = "id"
+	cfg.SecretKey = "key"
+	cfg.Endpoint = server.URL + "/"
+	cfg.UserAgent = "config-agent"
+	cfg.MaxRetries = 2
+	cfg.DefaultHeaders = http.Header{"X-Tenant": {"a"}}
+	cfg.LazyToken = true
+
+	c, err := NewClientFromConfig(cfg, WithMaxRetries(4))
+	if err != nil {
+		t.Fatalf("NewClientFromConfig() error = %v", err)
+	}
+	defer c.Close()
+
+	// Config is applied with the endpoint normalized
+	if c.config.Endpoint != server.URL {
+		t.Errorf("Endpoint = %q, want %q", c.config.Endpoint, server.URL)
+	}
+	if c.config.UserAgent != "config-agent" {
+		t.Errorf("UserAgent = %q, want %q", c.config.UserAgent, "config-agent")
+	}
+	if !c.config.LazyToken {
+		t.Error("LazyToken = false, want true")
+	}
+
+	// Options override the config
+	if c.config.MaxRetries != 4 {
+		t.Errorf("MaxRetries = %d, want 4", c.config.MaxRetries)
+	}
+
+	// Headers are copied from the config
+	cfg.DefaultHeaders.Set("X-Tenant", "b")
+	if got := c.config.DefaultHeaders.Get("X-Tenant"); got != "a" {
+		t.Errorf("DefaultHeaders X-Tenant = %q, want %q", got, "a")
+	}
+}
+
+func TestNewClientFromConfigInvalid(t *testing.T) {
+	_, err := NewClientFromConfig(ClientConfig{SecretID: "id", SecretKey: "key"})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewClientFromConfig() error = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestConfigSecretKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(path, []byte(" file-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var authorization string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	})
+
+	cfg := DefaultConfig()
+	cfg.SecretID = "id"
+	cfg.SecretKeyFile = path
+	cfg.Endpoint = server.URL
+	cfg.EnableToken = false
+
+	c, err := NewClientFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewClientFromConfig() error = %v", err)
+	}
+	defer c.Close()
+
+	if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithKey(); result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+	if want := "Basic id:file-key"; authorization != want {
+		t.Errorf("Authorization = %q, want %q", authorization, want)
+	}
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithDedupCache sets how long results of calls with an idempotency key are kept, so the same
+// operation attempted again within ttl, or while in flight, gets the result of the first attempt
+// without a duplicate call, marked by Result.FromCache. Only successful results are kept, so failed
+// ones, by error or API code, are shared with calls waiting in flight but not with later attempts.
+func WithDedupCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.dedup = &dedupCache{
+			ttl:     ttl,
+			entries: make(map[string]*dedupEntry),
+		}
+	}
+}
+
+// dedupCache provides results by idempotency key shared by a client
+type dedupCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*dedupEntry
+}
+
+// dedupEntry provides the result of an operation, available once done is closed
+type dedupEntry struct {
+	done   chan struct{}
+	result *Result
+	expiry time.Time
+}
+
+// do returns the kept result of key, waiting for it if in flight, or calls send and keeps its result
+func (d *dedupCache) do(ctx context.Context, key string, send func() *Result) *Result {
+	d.mu.Lock()
+
+	// Drop expired results
+	now := time.Now()
+	for k, entry := range d.entries {
+		if !entry.expiry.IsZero() && now.After(entry.expiry) {
+			delete(d.entries, k)
+		}
+	}
+
+	// Share result of the same operation
+	if entry, ok := d.entries[key]; ok {
+		d.mu.Unlock()
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return &Result{Err: ctx.Err()}
+		}
+
+		result := *entry.result
+		result.FromCache = true
+		return &result
+	}
+
+	entry := &dedupEntry{done: make(chan struct{})}
+	d.entries[key] = entry
+	d.mu.Unlock()
+
+	// Send and keep result if succeeded
+	entry.result = send()
+	d.mu.Lock()
+	if entry.result.Err != nil || !entry.result.OK() {
+		delete(d.entries, key)
+	} else {
+		entry.expiry = time.Now().Add(d.ttl)
+	}
+	d.mu.Unlock()
+	close(entry.done)
+
+	// Return a copy so the kept result is not altered by the caller
+	result := *entry.result
+	return &result
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDedupCacheConcurrent(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		writeEnvelope(w, CodeSuccess, "ok", `{"id":1}`)
+	})
+	c := newTestClient(t, server, WithDedupCache(time.Minute))
+
+	// Send identical operations at once
+	const callers = 10
+	results := make([]*Result, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Go(func() {
+			results[i] = c.Send(c.URL("/op"), http.MethodPost, map[string]int{"n": 1}, WithIdempotencyKey("op-1")).WithToken()
+		})
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("upstream called %d times, want 1", n)
+	}
+	fromCache := 0
+	for _, result := range results {
+		if result.Err != nil || !result.OK() {
+			t.Fatalf("result error = %v, code = %d", result.Err, result.Code)
+		}
+		if result.FromCache {
+			fromCache++
+		}
+	}
+	if fromCache != callers-1 {
+		t.Errorf("%d results from cache, want %d", fromCache, callers-1)
+	}
+
+	// Expect later attempts within ttl from cache too
+	result := c.Send(c.URL("/op"), http.MethodPost, map[string]int{"n": 1}, WithIdempotencyKey("op-1")).WithToken()
+	if !result.FromCache || calls.Load() != 1 {
+		t.Errorf("later attempt FromCache = %v, upstream calls = %d, want true and 1", result.FromCache, calls.Load())
+	}
+}
+
+func TestDedupCacheSkipsFailedResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"api code", func(w http.ResponseWriter, r *http.Request) {
+			writeEnvelope(w, CodeServerError, "failed", "null")
+		}},
+		{"status", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				tt.handler(w, r)
+			})
+			c := newTestClient(t, server, WithDedupCache(time.Minute), WithMaxRetries(1))
+
+			for range 2 {
+				result := c.Send(c.URL("/op"), http.MethodPost, nil, WithIdempotencyKey("op-1")).WithToken()
+				if result.FromCache {
+					t.Fatal("failed result replayed from cache")
+				}
+			}
+			if n := calls.Load(); n != 2 {
+				t.Errorf("upstream called %d times, want 2", n)
+			}
+		})
+	}
+}
+
+func TestDedupCacheExpiry(t *testing.T) {
+	var calls atomic.Int32
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	})
+	c := newTestClient(t, server, WithDedupCache(50*time.Millisecond))
+
+	c.Send(c.URL("/op"), http.MethodPost, nil, WithIdempotencyKey("op-1")).WithToken()
+	time.Sleep(100 * time.Millisecond)
+	if result := c.Send(c.URL("/op"), http.MethodPost, nil, WithIdempotencyKey("op-1")).WithToken(); result.FromCache {
+		t.Error("expired result replayed from cache")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("upstream called %d times, want 2", n)
+	}
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNoDefaultClient is returned when the default client is used before being set
+var ErrNoDefaultClient = errors.New("default client is not set, call client.SetDefault first")
+
+var (
+	defaultMu     sync.RWMutex
+	defaultClient *Client
+)
+
+// SetDefault sets the process-wide default client used by package-level convenience functions
+func SetDefault(c *Client) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultClient = c
+}
+
+// Default returns the process-wide default client
+func Default() (*Client, error) {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	if defaultClient == nil {
+		return nil, ErrNoDefaultClient
+	}
+
+	return defaultClient, nil
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"maps"
+	"sync"
+)
+
+// TokenEndpoint is the path of the token endpoint
+const TokenEndpoint = "/openAPI/token"
+
+// endpoints provides the registry of service endpoint paths by name
+var endpoints = struct {
+	mu    sync.RWMutex
+	paths map[string]string
+}{
+	paths: map[string]string{
+		"token": TokenEndpoint,
+	},
+}
+
+// RegisterEndpoint records the endpoint path of a service by name, service packages register
+// theirs on init
+func RegisterEndpoint(name string, path string) {
+	endpoints.mu.Lock()
+	defer endpoints.mu.Unlock()
+
+	endpoints.paths[name] = path
+}
+
+// Endpoints returns a copy of the registered endpoint paths by service name
+func Endpoints() map[string]string {
+	endpoints.mu.RLock()
+	defer endpoints.mu.RUnlock()
+
+	return maps.Clone(endpoints.paths)
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// envelopeFields provides the field names of the response envelope
+type envelopeFields struct {
+	code string
+	msg  string
+	data string
+}
+
+// defaultEnvelope provides the default field names of the response envelope
+var defaultEnvelope = envelopeFields{
+	code: "code",
+	msg:  "msg",
+	data: "data",
+}
+
+// ParseEnvelope decodes a response body of code, msg and data shape with codec, independent
+// of a live request, e.g. for stored responses or recorded fixtures
+func ParseEnvelope(codec Codec, body []byte) (*Result, error) {
+	result := parseEnvelope(codec, body, defaultEnvelope)
+	return result, result.Err
+}
+
+// parseEnvelope returns parsed body data, failures are reported in Result.Err
+func parseEnvelope(codec Codec, body []byte, fields envelopeFields) *Result {
+	var envelope map[string]json.RawMessage
+
+	// unmarshal body
+	if err := unmarshal(codec, body, &envelope); err != nil {
+		return &Result{
+			codec:       codec,
+			RawEnvelope: body,
+			Err:         newDecodeError(body, &envelope, err),
+		}
+	}
+
+	// Unmarshal code and msg part
+	var result struct {
+		Code int
+		Msg  string
+	}
+	if raw := envelopeField(envelope, fields.code); raw != nil {
+		if err := unmarshal(codec, raw, &result.Code); err != nil {
+			return &Result{
+				codec:       codec,
+				RawEnvelope: body,
+				Err:         newDecodeError(raw, &result.Code, err),
+			}
+		}
+	}
+	if raw := envelopeField(envelope, fields.msg); raw != nil {
+		if err := unmarshal(codec, raw, &result.Msg); err != nil {
+			return &Result{
+				codec:       codec,
+				RawEnvelope: body,
+				Err:         newDecodeError(raw, &result.Msg, err),
+			}
+		}
+	}
+
+	// Keep data part as is, so arrays and scalars are not altered by remarshalling
+	dataBody := envelopeField(envelope, fields.data)
+	if dataBody == nil {
+		dataBody = json.RawMessage("null")
+	}
+
+	// Return full result
+	return &Result{
+		codec:       codec,
+		Code:        result.Code,
+		Msg:         result.Msg,
+		Body:        dataBody,
+		RawEnvelope: body,
+	}
+}
+
+// envelopeField returns the raw envelope field by name, matching case-insensitively if no exact match
+func envelopeField(envelope map[string]json.RawMessage, name string) json.RawMessage {
+	if value, ok := envelope[name]; ok {
+		return value
+	}
+
+	for key, value := range envelope {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrTokenExpired is returned when the token expired and automatic refresh is disabled
+	ErrTokenExpired = errors.New("token expired")
+
+	// ErrTokenRefresh wraps the error of a token refresh failing during a request,
+	// as opposed to the request itself failing
+	ErrTokenRefresh = errors.New("token refresh failed")
+)
+
+var (
+	// ErrRequestTooLarge is returned when the payload exceeds the max request bytes
+	ErrRequestTooLarge = errors.New("request too large")
+
+	// ErrResponseTooLarge is returned when the response body exceeds the max response bytes
+	ErrResponseTooLarge = errors.New("response too large")
+)
+
+// ErrQuotaExceeded is matched by QuotaError when the upstream reports quota or rate exceeded
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError provides details of an exceeded quota, Reset is zero when unknown
+type QuotaError struct {
+	Code  int
+	Msg   string
+	Reset time.Time
+}
+
+// Error returns the error message
+func (e *QuotaError) Error() string {
+	if !e.Reset.IsZero() {
+		return fmt.Sprintf("quota exceeded, code: %d, msg: %s, reset at %s", e.Code, e.Msg, e.Reset.Format(time.RFC3339))
+	}
+
+	return fmt.Sprintf("quota exceeded, code: %d, msg: %s", e.Code, e.Msg)
+}
+
+// Is reports whether target is ErrQuotaExceeded
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// ErrServiceUnavailable is matched by ServiceUnavailableError when upstream is under maintenance
+var ErrServiceUnavailable = errors.New("service unavailable")
+
+// ServiceUnavailableError provides the maintenance message and the wait asked by upstream,
+// RetryAfter is zero when unknown
+type ServiceUnavailableError struct {
+	Msg        string
+	RetryAfter time.Duration
+}
+
+// Error returns the error message
+func (e *ServiceUnavailableError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("service unavailable: %s, retry after %s", e.Msg, e.RetryAfter)
+	}
+
+	return fmt.Sprintf("service unavailable: %s", e.Msg)
+}
+
+// Is reports whether target is ErrServiceUnavailable
+func (e *ServiceUnavailableError) Is(target error) bool {
+	return target == ErrServiceUnavailable
+}
+
+// WithErrorMapper sets a callback translating failed results, with a sender error or a code other
+// than success, into application errors, which replace Result.Err. Returning nil accepts the result,
+// so Result.Err is cleared and Expect and AsError report no error, while its code is kept.
+func WithErrorMapper(errorMapper func(*Result) error) Option {
+	return func(c *Client) {
+		c.errorMapper = errorMapper
+	}
+}
+
+// APIError provides the status, code and msg of a request rejected by upstream, RetryAfter is
+// the wait hinted by upstream, zero when not hinted
+type APIError struct {
+	StatusCode int
+	Code       int
+	Msg        string
+	RetryAfter time.Duration
+}
+
+// Error returns the error message
+func (e *APIError) Error() string {
+	return fmt.Sprintf("upstream failed: code: %d, msg: %s", e.Code, e.Msg)
+}
+
+// DefaultRetryHintHeader is the default header upstream hints the wait before retrying with, in milliseconds
+const DefaultRetryHintHeader = "X-Retry-After-Ms"
+
+// WithRetryHintHeader sets the header upstream hints the wait before retrying with, in milliseconds,
+// which is preferred to backoff, disabled if empty
+func WithRetryHintHeader(retryHintHeader string) Option {
+	return func(c *Client) {
+		c.retryHintHeader = retryHintHeader
+	}
+}
+
+// ErrRetryWaitTooLong is returned when upstream asks to wait longer than the max retry wait
+var ErrRetryWaitTooLong = errors.New("retry wait too long")
+
+// DefaultMaxRetryWait is the default max wait before retrying asked by upstream
+const DefaultMaxRetryWait = time.Minute
+
+// WithMaxRetryWait sets max wait before retrying asked by upstream by Retry-After or the retry hint,
+// failing with ErrRetryWaitTooLong instead of waiting longer, unlimited if not positive. Delays of
+// the backoff strategy are not capped.
+func WithMaxRetryWait(maxRetryWait time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetryWait = maxRetryWait
+	}
+}
+
+// checkRetryWait checks the wait before retrying asked by upstream does not exceed the max retry wait
+func (c *Client) checkRetryWait(wait time.Duration) error {
+	if c.maxRetryWait > 0 && wait > c.maxRetryWait {
+		return fmt.Errorf("%w: %s exceeds %s", ErrRetryWaitTooLong, wait, c.maxRetryWait)
+	}
+
+	return nil
+}
+
+// retryHint returns the wait hinted by upstream, or zero when not hinted
+func (c *Client) retryHint(header http.Header) time.Duration {
+	if c.retryHintHeader == "" || header == nil {
+		return 0
+	}
+
+	ms, err := strconv.ParseInt(header.Get(c.retryHintHeader), 10, 64)
+	if err != nil || ms <= 0 {
+		return 0
+	}
+
+	return time.Duration(ms) * time.Millisecond
+}
+
+// retryAfter returns how long upstream asks to wait, or zero when unknown
+func retryAfter(header http.Header) time.Duration {
+	reset := resetTime(header)
+	if reset.IsZero() {
+		return 0
+	}
+
+	return max(time.Until(reset), 0)
+}
+
+// resetTime returns when the upstream allows requests again from Retry-After or X-RateLimit-Reset,
+// or zero when unknown
+func resetTime(header http.Header) time.Time {
+	// Parse Retry-After in seconds or http date
+	if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return date
+		}
+	}
+
+	// Parse X-RateLimit-Reset in unix seconds
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(reset, 0)
+	}
+
+	return time.Time{}
+}
+
+// snippetSize is the maximum size of the body snippet kept by DecodeError
+const snippetSize = 128
+
+// DecodeError provides details of a failed decode of response body
+type DecodeError struct {
+	Target  string
+	URL     string
+	Offset  int64
+	Line    int
+	Snippet string
+	Err     error
+}
+
+// Error returns the error message
+func (e *DecodeError) Error() string {
+	target := e.Target
+	if e.URL != "" {
+		target = fmt.Sprintf("%s from %s", e.Target, e.URL)
+	}
+
+	if e.Offset > 0 {
+		return fmt.Sprintf(
+			"failed to decode %s at offset %d (line %d): %s, body: %q", target, e.Offset, e.Line, e.Err, e.Snippet,
+		)
+	}
+
+	return fmt.Sprintf("failed to decode %s: %s, body: %q", target, e.Err, e.Snippet)
+}
+
+// Unwrap returns the underlying decoder error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// newDecodeError wraps a decoder error with its position and a snippet of the offending body
+func newDecodeError(body []byte, v any, err error) *DecodeError {
+	decodeErr := &DecodeError{
+		Target: fmt.Sprint(reflect.TypeOf(v)),
+		Err:    err,
+	}
+
+	// Find error position
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		decodeErr.Offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		decodeErr.Offset = typeErr.Offset
+	}
+
+	// Cut snippet around position
+	start := 0
+	if decodeErr.Offset > 0 {
+		decodeErr.Line = bytes.Count(body[:min(int(decodeErr.Offset), len(body))], []byte("\n")) + 1
+		start = max(min(int(decodeErr.Offset), len(body))-snippetSize/2, 0)
+	}
+	end := min(start+snippetSize, len(body))
+	decodeErr.Snippet = string(body[start:end])
+
+	return decodeErr
+}
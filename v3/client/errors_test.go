@@ -0,0 +1,251 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxRetryWait(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		tooLong    bool
+		wantStatus int
+	}{
+		{"reasonable retry-after", "Retry-After", "1", false, http.StatusOK},
+		{"absurd retry-after", "Retry-After", "86400", true, http.StatusServiceUnavailable},
+		{"reasonable hint", DefaultRetryHintHeader, "10", false, http.StatusOK},
+		{"absurd hint", DefaultRetryHintHeader, "86400000", true, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					w.Header().Set(tt.header, tt.value)
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				writeEnvelope(w, CodeSuccess, "ok", "null")
+			})
+			c := newTestClient(t, server, WithMaxRetryWait(5*time.Second))
+
+			start := time.Now()
+			result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+			if tooLong := errors.Is(result.Err, ErrRetryWaitTooLong); tooLong != tt.tooLong {
+				t.Fatalf("error = %v, want ErrRetryWaitTooLong %v", result.Err, tt.tooLong)
+			}
+			if result.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", result.StatusCode, tt.wantStatus)
+			}
+			if tt.tooLong && time.Since(start) > time.Second {
+				t.Errorf("waited %v before failing", time.Since(start))
+			}
+		})
+	}
+}
+
+func TestMaxRetryWaitIgnoresBackoff(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	c := newTestClient(t, server,
+		WithMaxRetryWait(time.Second),
+		WithBackoff(ConstantBackoff{Delay: 2 * time.Minute}),
+	)
+
+	// Expect the backoff to be waited until the call times out
+	result := c.Send(c.URL("/data"), http.MethodGet, nil, WithCallTimeout(200*time.Millisecond)).WithToken()
+	if errors.Is(result.Err, ErrRetryWaitTooLong) {
+		t.Fatalf("error = %v, backoff delay capped", result.Err)
+	}
+	if !errors.Is(result.Err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", result.Err)
+	}
+}
+
+func TestNewDecodeError(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantOffset  int64
+		wantLine    int
+		wantSnippet string
+	}{
+		{"syntax", "{\n\"a\": ]", 8, 2, "{\n\"a\": ]"},
+		{"type", `{"a": "x"}`, 9, 1, `{"a": "x"}`},
+		{"long body", `{"a": ` + strings.Repeat(" ", 200) + `]`, 207, 1, strings.Repeat(" ", 63) + "]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v struct {
+				A int `json:"a"`
+			}
+			err := json.Unmarshal([]byte(tt.body), &v)
+			if err == nil {
+				t.Fatal("json.Unmarshal() error = nil, want error")
+			}
+
+			decodeErr := newDecodeError([]byte(tt.body), &v, err)
+			if decodeErr.Offset != tt.wantOffset {
+				t.Errorf("Offset = %d, want %d", decodeErr.Offset, tt.wantOffset)
+			}
+			if decodeErr.Line != tt.wantLine {
+				t.Errorf("Line = %d, want %d", decodeErr.Line, tt.wantLine)
+			}
+			if decodeErr.Snippet != tt.wantSnippet {
+				t.Errorf("Snippet = %q, want %q", decodeErr.Snippet, tt.wantSnippet)
+			}
+			if !errors.Is(decodeErr, err) {
+				t.Errorf("errors.Is(%v, %v) = false", decodeErr, err)
+			}
+		})
+	}
+}
+
+func TestEnvelopeDecodeError(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		_, _ = w.Write([]byte(`{"code":"x"}`))
+	})
+	c := newTestClient(t, server)
+
+	result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+	var decodeErr *DecodeError
+	if !errors.As(result.Err, &decodeErr) {
+		t.Fatalf("error = %v, want *DecodeError", result.Err)
+	}
+	if decodeErr.Target != "*int" || decodeErr.Snippet != `"x"` {
+		t.Errorf("DecodeError = %v, want the code field as target and snippet", decodeErr)
+	}
+}
+
+func TestServiceUnavailable(t *testing.T) {
+	tests := []struct {
+		name           string
+		retryAfter     string
+		maxRetries     int
+		wantRetryAfter time.Duration
+	}{
+		{"retried", "0", 3, 0},
+		{"retry after", "120", 1, 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.Header().Set("Retry-After", tt.retryAfter)
+				w.Header().Set("Content-Type", ContentTypeJSON)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"code":503,"msg":"under maintenance","data":null}`))
+			})
+			c := newTestClient(t, server, WithMaxRetries(tt.maxRetries))
+
+			result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+			var unavailable *ServiceUnavailableError
+			if !errors.As(result.Err, &unavailable) || !errors.Is(result.Err, ErrServiceUnavailable) {
+				t.Fatalf("error = %v, want *ServiceUnavailableError", result.Err)
+			}
+			if unavailable.Msg != "under maintenance" ||
+				unavailable.RetryAfter > tt.wantRetryAfter || unavailable.RetryAfter < tt.wantRetryAfter-time.Second {
+				t.Errorf("error = %+v, want maintenance message and RetryAfter about %v", unavailable, tt.wantRetryAfter)
+			}
+			if got := calls.Load(); got != int32(tt.maxRetries) {
+				t.Errorf("got %d calls, want %d", got, tt.maxRetries)
+			}
+		})
+	}
+}
+
+func TestAPIErrorRetryHint(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		header  string
+		value   string
+		want    time.Duration
+	}{
+		{"present", nil, DefaultRetryHintHeader, "1500", 1500 * time.Millisecond},
+		{"absent", nil, "", "", 0},
+		{"custom header", []Option{WithRetryHintHeader("X-Wait-Ms")}, "X-Wait-Ms", "250", 250 * time.Millisecond},
+		{"disabled", []Option{WithRetryHintHeader("")}, DefaultRetryHintHeader, "1500", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "" {
+					w.Header().Set(tt.header, tt.value)
+				}
+				writeEnvelope(w, CodeForbidden, "forbidden", "null")
+			})
+			c := newTestClient(t, server, tt.options...)
+
+			err := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken().RequireOK()
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("RequireOK() error = %v, want *APIError", err)
+			}
+			if apiErr.RetryAfter != tt.want {
+				t.Errorf("RetryAfter = %v, want %v", apiErr.RetryAfter, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotaCodes(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []Option
+		code      int
+		wantQuota bool
+	}{
+		{"default", nil, CodeQuotaExceeded, true},
+		{"custom", []Option{WithQuotaCodes(4290)}, 4290, true},
+		{"default kept with custom", []Option{WithQuotaCodes(4290)}, CodeQuotaExceeded, true},
+		{"default kept without codes", []Option{WithQuotaCodes()}, CodeQuotaExceeded, true},
+		{"not quota", []Option{WithQuotaCodes(4290)}, CodeForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.Header().Set("Retry-After", "60")
+				writeEnvelope(w, tt.code, "limited", "null")
+			})
+			c := newTestClient(t, server, tt.options...)
+
+			result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+			if result.IsRateLimited() != tt.wantQuota {
+				t.Errorf("IsRateLimited() = %v, want %v", result.IsRateLimited(), tt.wantQuota)
+			}
+			var quotaErr *QuotaError
+			if !tt.wantQuota {
+				if errors.As(result.Err, &quotaErr) {
+					t.Errorf("error = %v, want no QuotaError", result.Err)
+				}
+				return
+			}
+			if !errors.As(result.Err, &quotaErr) || !errors.Is(result.Err, ErrQuotaExceeded) {
+				t.Fatalf("error = %v, want *QuotaError", result.Err)
+			}
+			if quotaErr.Code != tt.code || quotaErr.Msg != "limited" {
+				t.Errorf("QuotaError = %+v, want code %d and msg limited", quotaErr, tt.code)
+			}
+			if wait := time.Until(quotaErr.Reset); wait <= 58*time.Second || wait > time.Minute {
+				t.Errorf("Reset in %v, want about a minute from Retry-After", wait)
+			}
+			if got := calls.Load(); got != 1 {
+				t.Errorf("got %d calls, want 1 as quota is left to the caller", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testToken is the token served by test servers
+const testToken = "test-token-0123456789"
+
+// writeEnvelope writes a response envelope of code, msg and data
+func writeEnvelope(w http.ResponseWriter, code int, msg string, data string) {
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	_, _ = fmt.Fprintf(w, `{"code":%d,"msg":%q,"data":%s}`, code, msg, data)
+}
+
+// newTestServer starts a server answering token requests with testToken and other requests with handler
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == TokenEndpoint {
+			writeEnvelope(w, CodeSuccess, "ok", fmt.Sprintf(`{"token":%q}`, testToken))
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestClient creates a client of server with logs discarded and no retry delay
+func newTestClient(t *testing.T, server *httptest.Server, options ...Option) *Client {
+	t.Helper()
+
+	options = append([]Option{
+		WithEndpoint(server.URL),
+		WithLogger(NewLogger(WithLoggerOutput(io.Discard))),
+		WithRetryDelay(0),
+	}, options...)
+	c, err := NewClient("id", "key", options...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	return c
+}
+
+// recordLogger records log lines of all levels
+type recordLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// record records a log line
+func (l *recordLogger) record(args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lines = append(l.lines, fmt.Sprint(args...))
+}
+
+// Debug records a Debug level log
+func (l *recordLogger) Debug(_ context.Context, args ...any) {
+	l.record(args...)
+}
+
+// Info records an Info level log
+func (l *recordLogger) Info(_ context.Context, args ...any) {
+	l.record(args...)
+}
+
+// Warn records a Warn level log
+func (l *recordLogger) Warn(_ context.Context, args ...any) {
+	l.record(args...)
+}
+
+// Error records an Error level log
+func (l *recordLogger) Error(_ context.Context, args ...any) {
+	l.record(args...)
+}
+
+// output returns the recorded lines joined
+func (l *recordLogger) output() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return strings.Join(l.lines, "\n")
+}
@@ -3,8 +3,11 @@ package client
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
+	"strings"
 )
 
 // Logger construct a basic interface for logger
@@ -15,10 +18,197 @@ type Logger interface {
 	Error(context.Context, ...any)
 }
 
+// LogPolicy provides a basic type for when bodies are logged
+type LogPolicy int
+
+// Log policies of bodies
+const (
+	LogBodyAlways LogPolicy = iota
+	LogBodyOnError
+	LogBodyNever
+)
+
+// WithLogPolicy sets when response bodies are logged, LogBodyOnError logs them only for
+// failed requests to limit noise and exposure of personal data
+func WithLogPolicy(logPolicy LogPolicy) Option {
+	return func(c *Client) {
+		c.logPolicy = logPolicy
+	}
+}
+
+// WithLogRequestBody sets whether request bodies are logged along with response bodies, off by
+// default as they carry personal data such as ID numbers and names. Secrets and ID numbers are
+// redacted as in recordings, other fields are logged as is.
+func WithLogRequestBody(logRequestBody bool) Option {
+	return func(c *Client) {
+		c.logRequestBody = logRequestBody
+	}
+}
+
+// Level provides a basic type for log levels
+type Level int
+
+// Log levels
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// WithCodeLogLevel sets the level logs about a response are written at by its API code,
+// codes not set keep the level of the log, e.g. Debug for routine 801 refreshes
+func WithCodeLogLevel(levels map[int]Level) Option {
+	return func(c *Client) {
+		c.codeLogLevel = make(map[int]Level, len(levels))
+		for code, level := range levels {
+			c.codeLogLevel[code] = level
+		}
+	}
+}
+
+// logCode writes log about a response of code at the level set for it, or at level
+func (c *Client) logCode(ctx context.Context, code int, level Level, args ...any) {
+	if codeLevel, ok := c.codeLogLevel[code]; ok {
+		level = codeLevel
+	}
+
+	switch level {
+	case LevelInfo:
+		c.Logger.Info(ctx, args...)
+	case LevelWarn:
+		c.Logger.Warn(ctx, args...)
+	case LevelError:
+		c.Logger.Error(ctx, args...)
+	default:
+		c.Logger.Debug(ctx, args...)
+	}
+}
+
+// DefaultMaxLogBodyBytes is the default max size of bodies logged
+const DefaultMaxLogBodyBytes = 4 << 10
+
+// WithMaxLogBodyBytes sets max size of request and response bodies logged, longer ones are
+// truncated in logs only, unlimited if not positive
+func WithMaxLogBodyBytes(maxLogBodyBytes int) Option {
+	return func(c *Client) {
+		c.maxLogBodyBytes = maxLogBodyBytes
+	}
+}
+
+// logBody returns body for logging, truncated to the max log body size with a marker
+func (c *Client) logBody(body []byte) string {
+	if c.maxLogBodyBytes <= 0 || len(body) <= c.maxLogBodyBytes {
+		return string(body)
+	}
+
+	return fmt.Sprintf("%s...(truncated %d bytes)", body[:c.maxLogBodyBytes], len(body)-c.maxLogBodyBytes)
+}
+
+// logFieldsKey is the context key of log fields
+type logFieldsKey struct{}
+
+// WithLogFields returns a context carrying fields rendered in log lines of requests made with it,
+// merged over fields already carried by ctx
+func WithLogFields(ctx context.Context, fields map[string]string) context.Context {
+	merged := make(map[string]string, len(fields))
+	for key, value := range LogFields(ctx) {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+// LogFields returns the log fields carried by ctx
+func LogFields(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(logFieldsKey{}).(map[string]string)
+	return fields
+}
+
+// formatFields renders the log fields carried by ctx in key order
+func formatFields(ctx context.Context) string {
+	fields := LogFields(ctx)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, fields[key]))
+	}
+
+	return fmt.Sprintf(" {%s}", strings.Join(pairs, ", "))
+}
+
+// LoggerOption provides a basic option type for the default logger
+type LoggerOption func(*loggerOptions)
+
+// loggerOptions provides settings of the default logger
+type loggerOptions struct {
+	output io.Writer
+	prefix string
+	flags  int
+	level  bool
+}
+
+// WithLoggerOutput sets output of the default logger
+func WithLoggerOutput(output io.Writer) LoggerOption {
+	return func(o *loggerOptions) {
+		o.output = output
+	}
+}
+
+// WithLoggerPrefix sets prefix of the default logger
+func WithLoggerPrefix(prefix string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.prefix = prefix
+	}
+}
+
+// WithLoggerFlags sets log flags of the default logger, such as timestamp format
+func WithLoggerFlags(flags int) LoggerOption {
+	return func(o *loggerOptions) {
+		o.flags = flags
+	}
+}
+
+// WithLoggerLevel sets whether the default logger includes the level
+func WithLoggerLevel(level bool) LoggerOption {
+	return func(o *loggerOptions) {
+		o.level = level
+	}
+}
+
 // NewLogger creates a new logger
-func NewLogger() Logger {
+func NewLogger(options ...LoggerOption) Logger {
+	// Load default options
+	o := &loggerOptions{
+		output: os.Stdout,
+		flags:  log.LstdFlags,
+		level:  true,
+	}
+
+	// Load options
+	for _, f := range options {
+		f(o)
+	}
+
 	logger := defaultLogger{
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+		logger: log.New(o.output, o.prefix, o.flags),
+		level:  o.level,
 	}
 	return logger
 }
@@ -26,24 +216,34 @@ func NewLogger() Logger {
 // defaultLogger is a sets of default internal logger methods
 type defaultLogger struct {
 	logger *log.Logger
+	level  bool
+}
+
+// print builds log of level
+func (l defaultLogger) print(ctx context.Context, level string, args ...any) {
+	if l.level {
+		l.logger.Printf("[%s] %s%s", level, fmt.Sprint(args...), formatFields(ctx))
+		return
+	}
+	l.logger.Printf("%s%s", fmt.Sprint(args...), formatFields(ctx))
 }
 
 // Debug build Debug level log
 func (l defaultLogger) Debug(ctx context.Context, args ...any) {
-	l.logger.Printf("[Debug] %s", fmt.Sprint(args...))
+	l.print(ctx, "Debug", args...)
 }
 
 // Info build Info level log
 func (l defaultLogger) Info(ctx context.Context, args ...any) {
-	l.logger.Printf("[Info] %s", fmt.Sprint(args...))
+	l.print(ctx, "Info", args...)
 }
 
 // Warn build Warn level log
 func (l defaultLogger) Warn(ctx context.Context, args ...any) {
-	l.logger.Printf("[Warn] %s", fmt.Sprint(args...))
+	l.print(ctx, "Warn", args...)
 }
 
 // Error build Error level log
 func (l defaultLogger) Error(ctx context.Context, args ...any) {
-	l.logger.Printf("[Error] %s", fmt.Sprint(args...))
+	l.print(ctx, "Error", args...)
 }
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogBody(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxLogBodyBytes int
+		body            string
+		want            string
+	}{
+		{"short", 8, "abc", "abc"},
+		{"exact", 3, "abc", "abc"},
+		{"truncated", 3, "abcdef", "abc...(truncated 3 bytes)"},
+		{"unlimited", 0, "abcdef", "abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{maxLogBodyBytes: tt.maxLogBodyBytes}
+			if got := c.logBody([]byte(tt.body)); got != tt.want {
+				t.Errorf("logBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxLogBodyBytes(t *testing.T) {
+	data := `"` + strings.Repeat("x", 100) + `"`
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, CodeSuccess, "ok", data)
+	})
+	logger := new(recordLogger)
+	c := newTestClient(t, server, WithLogger(logger), WithMaxLogBodyBytes(16))
+
+	result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+	if result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+
+	// Expect truncation in logs only
+	if !strings.Contains(logger.output(), "...(truncated ") {
+		t.Errorf("log has no truncation mark:\n%s", logger.output())
+	}
+	if string(result.Body) != data {
+		t.Errorf("Body = %s, want %s", result.Body, data)
+	}
+}
+
+func TestLogRequestBody(t *testing.T) {
+	payload := map[string]string{
+		"id":   "11010519491231002X",
+		"name": "test name",
+	}
+	tests := []struct {
+		name    string
+		options []Option
+		logged  bool
+	}{
+		{"default", nil, false},
+		{"opted in", []Option{WithLogRequestBody(true)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				writeEnvelope(w, CodeSuccess, "ok", `{"ok":true}`)
+			})
+			logger := new(recordLogger)
+			c := newTestClient(t, server, append([]Option{WithLogger(logger)}, tt.options...)...)
+
+			if result := c.Send(c.URL("/cnid"), http.MethodPost, payload).WithToken(); result.Err != nil {
+				t.Fatalf("Send() error = %v", result.Err)
+			}
+
+			output := logger.output()
+			if strings.Contains(output, "11010519491231002X") {
+				t.Errorf("log has ID number:\n%s", output)
+			}
+			if logged := strings.Contains(output, "requestBody"); logged != tt.logged {
+				t.Errorf("request body logged = %v, want %v:\n%s", logged, tt.logged, output)
+			}
+		})
+	}
+}
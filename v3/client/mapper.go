@@ -0,0 +1,180 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// WithFieldNameMapper sets a mapper from struct field names to wire field names, applied to
+// object keys of payloads marshalled and reversed on responses unmarshalled into structs
+func WithFieldNameMapper(mapper func(string) string) Option {
+	return func(c *Client) {
+		c.fieldNameMapper = mapper
+	}
+}
+
+// SnakeCase maps a field name such as linkID to link_id
+func SnakeCase(name string) string {
+	runes := []rune(name)
+
+	var mapped strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				mapped.WriteRune('_')
+			}
+		}
+		mapped.WriteRune(unicode.ToLower(r))
+	}
+
+	return mapped.String()
+}
+
+// mapperCodec renames object keys around a codec
+type mapperCodec struct {
+	codec  Codec
+	mapper func(string) string
+}
+
+// Marshal marshals v and maps its object keys
+func (m mapperCodec) Marshal(v any) ([]byte, error) {
+	data, err := m.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := decodeTree(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(m.mapKeys(tree))
+}
+
+// Unmarshal reverses the mapped object keys of the structs in v and unmarshals data into it
+func (m mapperCodec) Unmarshal(data []byte, v any) error {
+	tree, err := decodeTree(data)
+	if err != nil {
+		return m.codec.Unmarshal(data, v)
+	}
+
+	data, err = json.Marshal(m.unmapKeys(tree, reflect.TypeOf(v)))
+	if err != nil {
+		return err
+	}
+
+	return m.codec.Unmarshal(data, v)
+}
+
+// decodeTree decodes data keeping numbers intact
+func decodeTree(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var tree any
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// mapKeys maps all object keys of tree
+func (m mapperCodec) mapKeys(tree any) any {
+	switch node := tree.(type) {
+	case map[string]any:
+		mapped := make(map[string]any, len(node))
+		for key, value := range node {
+			mapped[m.mapper(key)] = m.mapKeys(value)
+		}
+		return mapped
+	case []any:
+		for i, value := range node {
+			node[i] = m.mapKeys(value)
+		}
+		return node
+	default:
+		return tree
+	}
+}
+
+// unmapKeys reverses mapped object keys of tree where t is a struct
+func (m mapperCodec) unmapKeys(tree any, t reflect.Type) any {
+	// Dereference pointers
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return tree
+	}
+
+	switch node := tree.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := make(map[string]reflect.StructField)
+			m.structFields(t, fields)
+			unmapped := make(map[string]any, len(node))
+			for key, value := range node {
+				if field, ok := fields[key]; ok {
+					unmapped[jsonName(field)] = m.unmapKeys(value, field.Type)
+					continue
+				}
+				unmapped[key] = value
+			}
+			return unmapped
+		case reflect.Map:
+			for key, value := range node {
+				node[key] = m.unmapKeys(value, t.Elem())
+			}
+		}
+		return node
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, value := range node {
+				node[i] = m.unmapKeys(value, t.Elem())
+			}
+		}
+		return node
+	default:
+		return tree
+	}
+}
+
+// structFields collects exported fields of t by their mapped name, flattening embedded structs
+func (m mapperCodec) structFields(t reflect.Type, fields map[string]reflect.StructField) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		// Flatten untagged embedded structs
+		embedded := field.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" && embedded.Kind() == reflect.Struct {
+			m.structFields(embedded, fields)
+			continue
+		}
+
+		fields[m.mapper(name)] = field
+	}
+}
+
+// jsonName returns the json name of a struct field
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"linkID", "link_id"},
+		{"LinkID", "link_id"},
+		{"HTTPStatus", "http_status"},
+		{"idMatch", "id_match"},
+		{"ipv4Address", "ipv4_address"},
+		{"created_at", "created_at"},
+		{"id", "id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SnakeCase(tt.name); got != tt.want {
+				t.Errorf("SnakeCase(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// mapperItem is a nested struct of mapperPayload
+type mapperItem struct {
+	ItemID    string `json:"itemID"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// mapperPayload covers nested objects, arrays, maps and keys already in snake case
+type mapperPayload struct {
+	LinkID  string                `json:"linkID"`
+	Owner   mapperItem            `json:"owner"`
+	Items   []mapperItem          `json:"items"`
+	ByName  map[string]mapperItem `json:"byName"`
+	Tags    []string              `json:"tags"`
+	Expires int64                 `json:"expires_at"`
+}
+
+func TestMapperCodecRoundTrip(t *testing.T) {
+	codec := mapperCodec{codec: jsonCodec{unmarshal: unmarshalJSON}, mapper: SnakeCase}
+	want := mapperPayload{
+		LinkID:  "abc",
+		Owner:   mapperItem{ItemID: "o", CreatedAt: 1},
+		Items:   []mapperItem{{ItemID: "a", CreatedAt: 2}, {ItemID: "b", CreatedAt: 3}},
+		ByName:  map[string]mapperItem{"firstKey": {ItemID: "c", CreatedAt: 4}},
+		Tags:    []string{"camelCase"},
+		Expires: 9007199254740993,
+	}
+
+	data, err := codec.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	// Check keys on the wire, map keys are object keys too
+	var wire map[string]any
+	if err = json.Unmarshal(data, &wire); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	for _, key := range []string{"link_id", "owner", "items", "by_name", "tags", "expires_at"} {
+		if _, ok := wire[key]; !ok {
+			t.Errorf("wire %s misses key %s", data, key)
+		}
+	}
+	if item := wire["items"].([]any)[0].(map[string]any); item["item_id"] != "a" || item["created_at"] == nil {
+		t.Errorf("wire item = %v, want item_id and created_at", item)
+	}
+	if _, ok := wire["by_name"].(map[string]any)["first_key"]; !ok {
+		t.Errorf("wire by_name = %v, want first_key", wire["by_name"])
+	}
+
+	var got mapperPayload
+	if err = codec.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want.ByName = map[string]mapperItem{"first_key": want.ByName["firstKey"]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestFieldNameMapper(t *testing.T) {
+	var body []byte
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		writeEnvelope(w, CodeSuccess, "ok", `{"item_id":"a","created_at":2}`)
+	})
+	c := newTestClient(t, server, WithFieldNameMapper(SnakeCase))
+
+	result := c.Send(c.URL("/data"), http.MethodPost, mapperItem{ItemID: "x", CreatedAt: 1}).WithToken()
+	if result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+	if string(body) != `{"created_at":1,"item_id":"x"}` {
+		t.Errorf("body = %s, want snake case keys", body)
+	}
+	var got mapperItem
+	if err := result.DecodeInto(&got); err != nil || got != (mapperItem{ItemID: "a", CreatedAt: 2}) {
+		t.Errorf("DecodeInto() = %+v, error = %v, want item a", got, err)
+	}
+}
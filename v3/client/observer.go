@@ -0,0 +1,54 @@
+package client
+
+import "time"
+
+// Observer provides a basic interface for observing calls and token refreshes, e.g. to export metrics.
+// Methods are called synchronously from the goroutines making calls, so they must be fast and safe
+// for concurrent use.
+type Observer interface {
+	// CallStarted is called when a call starts sending, before its first attempt
+	CallStarted(method string)
+	// CallFinished is called when a call is done, after its retries
+	CallFinished(event CallEvent)
+	// TokenRefreshed is called after each token refresh, err is nil on success
+	TokenRefreshed(err error)
+}
+
+// CallEvent provides the outcome of a call reported to an Observer, StatusCode and Code are zero
+// when no response is received
+type CallEvent struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Code       int
+	Attempts   int
+	Duration   time.Duration
+	Err        error
+}
+
+// WithObserver sets observer of calls and token refreshes
+func WithObserver(observer Observer) Option {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
+// observe sends the call by send, reporting it to the observer
+func (s *Sender) observe(send func() *Result) *Result {
+	observer := s.client.observer
+	observer.CallStarted(s.request.Method)
+	start := time.Now()
+
+	result := send()
+	observer.CallFinished(CallEvent{
+		Method:     s.request.Method,
+		URL:        s.request.URL.String(),
+		StatusCode: result.StatusCode,
+		Code:       result.Code,
+		Attempts:   s.attempts,
+		Duration:   time.Since(start),
+		Err:        result.Err,
+	})
+
+	return result
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrUnreachable is returned by Ping when no response is received from upstream
+	ErrUnreachable = errors.New("upstream unreachable")
+
+	// ErrUnauthorized is returned by Ping when upstream rejects the credentials
+	ErrUnauthorized = errors.New("credentials rejected")
+
+	// ErrDegraded is returned by Ping when upstream responds but fails to serve
+	ErrDegraded = errors.New("service degraded")
+)
+
+// Ping checks connectivity and credentials by requesting a token with the secret key,
+// for startup or readiness checks. The token is not saved. It is sent once without retries,
+// so a probe learns of a failure within one round trip. Failures match ErrUnreachable,
+// ErrUnauthorized or ErrDegraded. Pass WithHeadersOnly to succeed on headers alone, which
+// skips checking the API code, so rejected credentials are only caught by HTTP status.
+func Ping(c *Client, options ...CallOption) error {
+	// Send request once
+	result := c.Send(
+		c.URL(TokenEndpoint),
+		http.MethodGet,
+		nil,
+		append([]CallOption{WithNoRetry()}, options...)...,
+	).WithKey()
+
+	// Classify failure
+	switch {
+	case result.Err != nil && result.StatusCode == 0:
+		return fmt.Errorf("%w: %w", ErrUnreachable, result.Err)
+	case result.IsUnauthorized() || result.StatusCode == http.StatusForbidden,
+		result.Code == CodeForbidden || result.Code == CodeTokenExpired:
+		return fmt.Errorf("%w: status: %d, code: %d, msg: %s", ErrUnauthorized, result.StatusCode, result.Code, result.Msg)
+	case result.Err != nil:
+		return fmt.Errorf("%w: %w", ErrDegraded, result.Err)
+	case !result.OK():
+		return fmt.Errorf("%w: status: %d, code: %d, msg: %s", ErrDegraded, result.StatusCode, result.Code, result.Msg)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPingHeadersOnly(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Send headers, then hold the body until the test ends
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() {
+		close(release)
+	})
+	c := newTestClient(t, server, WithLazyToken(true))
+
+	start := time.Now()
+	if err := Ping(c, WithHeadersOnly()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Ping() took %v, want return on headers", elapsed)
+	}
+
+	// Without headers only, the slow body is waited for until the call times out
+	err := Ping(c, WithCallTimeout(100*time.Millisecond), WithNoRetry())
+	if !errors.Is(err, ErrUnreachable) && !errors.Is(err, ErrDegraded) {
+		t.Errorf("Ping() error = %v, want failure on the slow body", err)
+	}
+}
+
+func TestPingRejectedKey(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeEnvelope(w, CodeTokenExpired, "invalid key", "null")
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, server, WithLazyToken(true), WithBackoff(ConstantBackoff{Delay: time.Minute}))
+
+	start := time.Now()
+	if err := Ping(c); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Ping() error = %v, want ErrUnauthorized", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Ping() took %v, want one round trip", elapsed)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("got %d calls, want 1", got)
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBytes bounds the capacity of buffers put back, so a rare large payload does not stay pinned
+const maxPooledBytes = 64 << 10
+
+// payloadPool provides reusable buffers of marshalled payloads
+var payloadPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// WithBufferPool sets whether payloads are marshalled into buffers reused across calls, saving
+// allocations for high-throughput callers. It applies to codecs implementing BufferCodec, such
+// as the default JSON codec. A buffer is reused only once the call, its retries and the transport
+// are done with it.
+func WithBufferPool(bufferPool bool) Option {
+	return func(c *Client) {
+		c.bufferPool = bufferPool
+	}
+}
+
+// pooledPayload is a payload in a pooled buffer, put back once all references are released.
+// The sender holds one reference and every body handed to the transport holds another.
+type pooledPayload struct {
+	buf  *bytes.Buffer
+	refs atomic.Int32
+	once sync.Once
+}
+
+// newPooledPayload marshals v with codec into a pooled buffer held by the sender
+func newPooledPayload(codec BufferCodec, v any) (*pooledPayload, error) {
+	buf := payloadPool.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	p := &pooledPayload{buf: buf}
+	p.refs.Store(1)
+	if err := marshalTo(codec, buf, v); err != nil {
+		p.done()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// body returns a new body reading the payload, releasing its reference on close
+func (p *pooledPayload) body() (io.ReadCloser, error) {
+	p.refs.Add(1)
+	return &pooledBody{
+		Reader:  bytes.NewReader(p.buf.Bytes()),
+		payload: p,
+	}, nil
+}
+
+// done releases the reference held by the sender at most once, doing nothing for no payload
+func (p *pooledPayload) done() {
+	if p != nil {
+		p.once.Do(p.release)
+	}
+}
+
+// release releases a reference, putting the buffer back once none are left
+func (p *pooledPayload) release() {
+	if p.refs.Add(-1) > 0 {
+		return
+	}
+
+	if p.buf.Cap() <= maxPooledBytes {
+		payloadPool.Put(p.buf)
+	}
+}
+
+// pooledBody reads a pooled payload for one attempt
+type pooledBody struct {
+	*bytes.Reader
+	payload *pooledPayload
+	once    sync.Once
+}
+
+// Close releases the reference of the body to the payload
+func (b *pooledBody) Close() error {
+	b.once.Do(b.payload.release)
+	return nil
+}
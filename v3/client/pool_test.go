@@ -0,0 +1,80 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBufferPoolRetries(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	var calls atomic.Int32
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	})
+	c := newTestClient(t, server, WithBufferPool(true), WithMaxRetries(3))
+
+	// Marshal other payloads in between, which must not reuse the buffer of the call
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				pooled, err := newPooledPayload(jsonCodec{unmarshal: unmarshalJSON}, map[string]string{"other": "payload"})
+				if err == nil {
+					pooled.done()
+				}
+			}
+		})
+	}
+	result := c.Send(c.URL("/data"), http.MethodPost, map[string]string{"name": "value"}).WithToken()
+	wg.Wait()
+	if result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, body := range bodies {
+		if body != `{"name":"value"}` {
+			t.Errorf("attempt %d: body = %s, want {\"name\":\"value\"}", i+1, body)
+		}
+	}
+}
+
+func BenchmarkPayload(b *testing.B) {
+	payload := struct {
+		Name   string `json:"name"`
+		Values []int  `json:"values"`
+	}{"<x>", make([]int, 2000)}
+
+	for _, bufferPool := range []bool{false, true} {
+		name := "plain"
+		if bufferPool {
+			name = "pooled"
+		}
+		b.Run(name, func(b *testing.B) {
+			c := &Client{codecs: map[string]Codec{ContentTypeJSON: jsonCodec{unmarshal: unmarshalJSON}}, bufferPool: bufferPool}
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, _, pooled, err := c.payload(payload, &callOptions{contentType: ContentTypeJSON})
+					if err != nil {
+						b.Fatal(err)
+					}
+					pooled.done()
+				}
+			})
+		})
+	}
+}
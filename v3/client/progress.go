@@ -0,0 +1,30 @@
+package client
+
+import "io"
+
+// WithUploadProgress sets a callback reporting bytes of the payload sent so far out of total,
+// which is -1 when unknown, e.g. for streamed payloads. It restarts from zero on retries.
+func WithUploadProgress(progress func(sent int64, total int64)) CallOption {
+	return func(o *callOptions) {
+		o.uploadProgress = progress
+	}
+}
+
+// progressBody reports bytes read from the body as they are sent
+type progressBody struct {
+	io.ReadCloser
+	sent     int64
+	total    int64
+	progress func(sent int64, total int64)
+}
+
+// Read reads from the body and reports progress
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.sent += int64(n)
+		b.progress(b.sent, b.total)
+	}
+
+	return n, err
+}
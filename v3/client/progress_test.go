@@ -0,0 +1,58 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestUploadProgress(t *testing.T) {
+	const size = 1 << 20
+	tests := []struct {
+		name      string
+		payload   func() any
+		wantTotal int64
+	}{
+		{"bytes", func() any { return make([]byte, size) }, size},
+		{"stream", func() any { return io.MultiReader(bytes.NewReader(make([]byte, size))) }, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				writeEnvelope(w, CodeSuccess, "ok", "null")
+			})
+			c := newTestClient(t, server)
+
+			var mu sync.Mutex
+			var sents []int64
+			progress := WithUploadProgress(func(sent int64, total int64) {
+				mu.Lock()
+				defer mu.Unlock()
+				if total != tt.wantTotal {
+					t.Errorf("total = %d, want %d", total, tt.wantTotal)
+				}
+				sents = append(sents, sent)
+			})
+			if result := c.Send(c.URL("/upload"), http.MethodPost, tt.payload(), progress).WithToken(); result.Err != nil {
+				t.Fatalf("Send() error = %v", result.Err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(sents) < 2 {
+				t.Fatalf("progress reported %d times, want several", len(sents))
+			}
+			for i := 1; i < len(sents); i++ {
+				if sents[i] <= sents[i-1] {
+					t.Fatalf("sent %d after %d, want increasing", sents[i], sents[i-1])
+				}
+			}
+			if last := sents[len(sents)-1]; last != size {
+				t.Errorf("last sent = %d, want %d", last, size)
+			}
+		})
+	}
+}
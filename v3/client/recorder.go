@@ -0,0 +1,205 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+)
+
+// RecordMode provides a basic type for how the recorder uses its recordings
+type RecordMode int
+
+// Record modes
+const (
+	// RecordModeReplay answers requests from the recordings only, failing on unrecorded ones
+	RecordModeReplay RecordMode = iota
+	// RecordModeRecord sends requests upstream and records them, replacing the recordings
+	RecordModeRecord
+	// RecordModeAuto replays when the recordings exist and records otherwise
+	RecordModeAuto
+)
+
+// ErrNotRecorded is returned in replay when no recorded interaction matches the request
+var ErrNotRecorded = errors.New("request not recorded")
+
+// redacted replaces secrets in recordings
+const redacted = "REDACTED"
+
+// Secrets redacted in recorded bodies, such as tokens, secret keys and Chinese Mainland IDs
+var (
+	secretFieldPattern = regexp.MustCompile(`("(?i:token|secretKey|secret_key|id|idNumber)"\s*:\s*)"[^"]*"`)
+	idNumberPattern    = regexp.MustCompile(`\b\d{17}[\dXx]\b`)
+)
+
+// Headers redacted in recordings
+var secretHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// WithRecorder records interactions with upstream to the file at path and replays them, so tests
+// run without credentials or network. Authorisation headers, tokens and ID numbers are redacted.
+// Replay matches requests by method and URL in recorded order.
+func WithRecorder(path string, mode RecordMode) Option {
+	return func(c *Client) {
+		recorder := &recorder{
+			path: path,
+			mode: mode,
+		}
+
+		// Load recordings
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil && mode != RecordModeRecord:
+			if err = json.Unmarshal(data, &recorder.interactions); err != nil {
+				c.optionErr = fmt.Errorf("failed to load recordings: %w", err)
+				return
+			}
+			recorder.mode = RecordModeReplay
+		case errors.Is(err, os.ErrNotExist) && mode == RecordModeAuto, mode == RecordModeRecord:
+			recorder.mode = RecordModeRecord
+		default:
+			c.optionErr = fmt.Errorf("failed to load recordings: %w", err)
+			return
+		}
+
+		c.recorder = recorder
+	}
+}
+
+// interaction provides a recorded request and its response
+type interaction struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+	StatusCode int         `json:"statusCode"`
+	ResHeader  http.Header `json:"responseHeader"`
+	ResBody    string      `json:"responseBody"`
+	replayed   bool
+}
+
+// recorder is a transport recording or replaying interactions
+type recorder struct {
+	base         http.RoundTripper
+	path         string
+	mode         RecordMode
+	mu           sync.Mutex
+	interactions []*interaction
+}
+
+// RoundTrip records the request and response, or replays the recorded response
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == RecordModeReplay {
+		return r.replay(req)
+	}
+
+	// Read request body, leaving it to be sent
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// Send request
+	res, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read response body, leaving it to be read
+	resBody, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	// Save redacted interaction, whose body length may change
+	resHeader := redactHeader(res.Header)
+	resHeader.Del("Content-Length")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, &interaction{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Header:     redactHeader(req.Header),
+		Body:       redactBody(body),
+		StatusCode: res.StatusCode,
+		ResHeader:  resHeader,
+		ResBody:    redactBody(resBody),
+	})
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(r.path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to save recordings: %w", err)
+	}
+
+	return res, nil
+}
+
+// replay returns the response of the first recorded interaction of the request not yet replayed
+func (r *recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, recorded := range r.interactions {
+		if recorded.replayed || recorded.Method != req.Method || recorded.URL != req.URL.String() {
+			continue
+		}
+		recorded.replayed = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.ResHeader.Clone(),
+			Body:          io.NopCloser(bytes.NewReader([]byte(recorded.ResBody))),
+			ContentLength: int64(len(recorded.ResBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL)
+}
+
+// CloseIdleConnections closes idle connections of the base transport
+func (r *recorder) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+
+	if transport, ok := r.base.(closeIdler); ok {
+		transport.CloseIdleConnections()
+	}
+}
+
+// redactHeader returns a copy of header with secrets redacted
+func redactHeader(header http.Header) http.Header {
+	redactedHeader := header.Clone()
+	for _, key := range secretHeaders {
+		if redactedHeader.Get(key) != "" {
+			redactedHeader.Set(key, redacted)
+		}
+	}
+
+	return redactedHeader
+}
+
+// redactBody returns body with secrets redacted
+func redactBody(body []byte) string {
+	body = secretFieldPattern.ReplaceAll(body, []byte(`${1}"`+redacted+`"`))
+	body = idNumberPattern.ReplaceAll(body, []byte(redacted))
+	return string(body)
+}
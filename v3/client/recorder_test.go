@@ -0,0 +1,117 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRecorderRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	// Record against a live server
+	var calls atomic.Int32
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeEnvelope(w, CodeSuccess, "ok", `{"name":"alice"}`)
+	})
+	recording := newTestClient(t, server, WithRecorder(path, RecordModeRecord))
+	if result := recording.Send(recording.URL("/data"), http.MethodGet, nil).WithToken(); result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+	_ = recording.Close()
+	server.Close()
+
+	// Replay with the server gone
+	replaying := newTestClient(t, server, WithRecorder(path, RecordModeReplay))
+	result := replaying.Send(replaying.URL("/data"), http.MethodGet, nil).WithToken()
+	if result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+	var data struct {
+		Name string `json:"name"`
+	}
+	if err := result.DecodeInto(&data); err != nil {
+		t.Fatalf("DecodeInto() error = %v", err)
+	}
+	if data.Name != "alice" {
+		t.Errorf("Name = %q, want %q", data.Name, "alice")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("got %d upstream calls, want 1", got)
+	}
+
+	// Replay each interaction once
+	result = replaying.Send(replaying.URL("/data"), http.MethodGet, nil, WithNoRetry()).WithToken()
+	if !errors.Is(result.Err, ErrNotRecorded) {
+		t.Errorf("Send() error = %v, want ErrNotRecorded", result.Err)
+	}
+}
+
+func TestRecorderMissingCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+
+	tests := []struct {
+		name    string
+		mode    RecordMode
+		wantErr bool
+	}{
+		{"replay", RecordModeReplay, true},
+		{"auto", RecordModeAuto, false},
+		{"record", RecordModeRecord, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient("id", "key",
+				WithLogger(NewLogger(WithLoggerOutput(io.Discard))), WithRecorder(path, tt.mode), WithLazyToken(true),
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClient() error = %v, want error %v", err, tt.wantErr)
+			}
+			if c != nil {
+				_ = c.Close()
+			}
+			if tt.wantErr && !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("NewClient() error = %v, want os.ErrNotExist", err)
+			}
+		})
+	}
+}
+
+func TestRecorderRedaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret-session")
+		writeEnvelope(w, CodeSuccess, "ok", `{"idNumber":"11010519491231002X","token":"reply-token"}`)
+	})
+	c := newTestClient(t, server, WithRecorder(path, RecordModeRecord))
+	payload := map[string]string{"secretKey": "payload-key", "note": "id 11010519491231002X"}
+	if result := c.Send(c.URL("/data"), http.MethodPost, payload).WithToken(); result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+	if result := c.Send(c.URL("/data"), http.MethodPost, payload).WithKey(); result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := string(data)
+	for _, secret := range []string{
+		testToken, "reply-token", "payload-key", "11010519491231002X", "secret-session", "Basic id:key",
+	} {
+		if strings.Contains(recorded, secret) {
+			t.Errorf("recordings contain %q", secret)
+		}
+	}
+	if !strings.Contains(recorded, redacted) {
+		t.Errorf("recordings do not contain %q", redacted)
+	}
+}
@@ -1,105 +1,325 @@
 package client
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
-
-	"go.gh.ink/openapi/sdk/20260422/v3"
 )
 
-// Result provides a basic struct to return result
+// Result provides a basic struct to return result, Body holds the data part of the response
+// and RawEnvelope the full response body as received
 type Result struct {
-	client *Client
-	Code   int
-	Msg    string
-	Body   []byte
-	Err    error
+	client      *Client
+	codec       Codec
+	StatusCode  int
+	URL         string
+	Code        int
+	Msg         string
+	Header      http.Header
+	Body        []byte
+	RawEnvelope []byte
+	Timings     *Timings
+	FromCache   bool
+	Err         error
+	accepted    bool
 }
 
-// Sender provides a basic struct to send request
+// Sender provides a basic struct to send request, it belongs to a single call
+// and must not be shared between goroutines
 type Sender struct {
-	client  *Client
-	request *http.Request
-	err     error
+	client         *Client
+	ctx            context.Context
+	codec          Codec
+	cancel         context.CancelFunc
+	request        *http.Request
+	noRetry        bool
+	headersOnly    bool
+	idempotencyKey string
+	uploadProgress func(sent int64, total int64)
+	payload        []byte
+	pooled         *pooledPayload
+	trace          *requestTrace
+	attempts       int
+	err            error
 }
 
-// Send provides a sender to send request
-func (c *Client) Send(url string, method string, payload any) *Sender {
+// Send provides a sender to send request to url, which is fully qualified and not limited to
+// the endpoint, so the same authorisation, retry and parse pipeline serves any URL. Use URL to
+// build endpoint-relative ones. Authorisation is sent to url as is, so only pass trusted hosts.
+// A non-nil payload is sent as the body with its content-type for any method, including GET for
+// APIs that expect it, while a nil payload sends no body, so standard GETs carry their
+// parameters in the query of url.
+func (c *Client) Send(url string, method string, payload any, options ...CallOption) *Sender {
+	// Load call options
+	opts := newCallOptions(c.baseCtx, options)
+
+	// Check client state
+	if c.isClosed() {
+		return &Sender{
+			client: c,
+			ctx:    opts.ctx,
+			cancel: opts.cancel,
+			err:    ErrClientClosed,
+		}
+	}
+
 	// Process payload
-	var finalPayload io.Reader = nil
-	if payload != nil {
-		// Marshal payload
-		jsonPayload, err := c.marshal(payload)
-		if err != nil {
-			return &Sender{
-				client: c,
-				err:    err,
-			}
+	finalPayload, payloadBytes, pooled, err := c.payload(payload, opts)
+	if err != nil {
+		return &Sender{
+			client: c,
+			ctx:    opts.ctx,
+			cancel: opts.cancel,
+			err:    err,
 		}
-		finalPayload = strings.NewReader(string(jsonPayload))
 	}
 
 	// Build http request
-	req, err := http.NewRequest(method, url, finalPayload)
+	req, err := http.NewRequestWithContext(opts.ctx, method, url, finalPayload)
 	if err != nil {
+		pooled.done()
 		return &Sender{
 			client: c,
+			ctx:    opts.ctx,
+			cancel: opts.cancel,
 			err:    err,
 		}
 	}
 
-	// Set content-type
-	if method == http.MethodPost {
-		req.Header.Add("Content-Type", "application/json")
+	// Merge call query params
+	if len(opts.query) > 0 {
+		query := req.URL.Query()
+		for key, values := range opts.query {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+
+	// Read pooled payloads through bodies holding their buffer
+	if pooled != nil {
+		req.ContentLength = int64(len(payloadBytes))
+		req.GetBody = pooled.body
+	}
+
+	// Rewind seekable readers on retry, and send other readers only once
+	noRetry := opts.noRetry
+	if finalPayload != nil && req.GetBody == nil {
+		if seeker, ok := finalPayload.(io.ReadSeeker); ok {
+			req.GetBody = func() (io.ReadCloser, error) {
+				if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+					return nil, err
+				}
+				return io.NopCloser(seeker), nil
+			}
+		} else {
+			noRetry = true
+			opts.ctx = context.WithValue(opts.ctx, noRetryKey{}, true) // Stop RetryTransport too
+		}
+	}
+
+	// Override Host, which Go sends from req.Host rather than the headers
+	if c.host != "" {
+		req.Host = c.host
+	}
+
+	// Accept JSON response
+	req.Header.Set("Accept", ContentTypeJSON)
+
+	// Set content-type of any body regardless of method
+	if finalPayload != nil {
+		req.Header.Set("Content-Type", opts.contentType)
+	}
+
+	// Set client default headers, context headers and then call headers, each replacing the former
+	setHeader(req.Header, c.config.DefaultHeaders)
+	for _, header := range c.contextHeaders {
+		if value := opts.ctx.Value(header.key); value != nil {
+			req.Header.Set(header.name, fmt.Sprint(value))
+		}
+	}
+	setHeader(req.Header, opts.header)
+	if opts.idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", opts.idempotencyKey)
 	}
 
 	// Return sender
 	return &Sender{
-		client:  c,
-		request: req,
-		err:     nil,
+		client:         c,
+		ctx:            opts.ctx,
+		codec:          opts.codec,
+		cancel:         opts.cancel,
+		request:        req,
+		noRetry:        noRetry,
+		headersOnly:    opts.headersOnly,
+		idempotencyKey: opts.idempotencyKey,
+		uploadProgress: opts.uploadProgress,
+		payload:        payloadBytes,
+		pooled:         pooled,
+		err:            nil,
 	}
 }
 
-// parse returns parsed body data
-func (s *Sender) parse(body []byte) *Result {
-	var result struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-		Data any    `json:"data"`
-	}
-
-	// unmarshal body
-	if err := s.client.unmarshal(body, &result); err != nil {
-		return &Result{
-			client: s.client,
-			Err:    err,
+// setHeader sets values of header into dst, replacing values of the same keys
+func setHeader(dst http.Header, header http.Header) {
+	for key, values := range header {
+		dst.Del(key)
+		for _, value := range values {
+			dst.Add(key, value)
 		}
 	}
+}
 
-	// Remarshal data part
-	dataBody, err := s.client.marshal(result.Data)
-	if err != nil {
-		return &Result{
-			client: s.client,
-			Err:    err,
+// payload returns the request body of payload and its bytes unless a reader, passing pre-serialised
+// bytes and readers through as is, and the pooled buffer holding them when marshalled into one
+func (c *Client) payload(payload any, opts *callOptions) (io.Reader, []byte, *pooledPayload, error) {
+	var data []byte
+	var pooled *pooledPayload
+	switch p := payload.(type) {
+	case nil:
+		return nil, nil, nil, nil
+	case json.RawMessage:
+		data = p
+	case []byte:
+		data = p
+	case io.Reader:
+		return p, nil, nil, nil
+	default:
+		// Marshal payload, into a pooled buffer when enabled
+		codec := opts.codec
+		if codec == nil {
+			codec = c.Codec(opts.contentType)
+		}
+		if bufferCodec, ok := codec.(BufferCodec); ok && c.bufferPool {
+			var err error
+			if pooled, err = newPooledPayload(bufferCodec, payload); err != nil {
+				return nil, nil, nil, err
+			}
+			data = pooled.buf.Bytes()
+		} else {
+			marshalled, err := marshal(codec, payload)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			data = marshalled
+		}
+		if mediaType(opts.contentType) == ContentTypeJSON && !json.Valid(data) {
+			pooled.done()
+			return nil, nil, nil, &CodecError{
+				Op:    "marshal",
+				Codec: fmt.Sprintf("%T", codec),
+				Err:   errors.New("invalid JSON produced"),
+			}
 		}
 	}
 
-	// Return full result
-	return &Result{
-		client: s.client,
-		Code:   result.Code,
-		Msg:    result.Msg,
-		Body:   dataBody,
+	// Check payload size
+	if c.config.MaxRequestBytes > 0 && int64(len(data)) > c.config.MaxRequestBytes {
+		pooled.done()
+		return nil, nil, nil, fmt.Errorf("%w: %d bytes exceeds %d", ErrRequestTooLarge, len(data), c.config.MaxRequestBytes)
 	}
+
+	return bytes.NewReader(data), data, pooled, nil
+}
+
+// parse returns parsed body data
+func (s *Sender) parse(body []byte, codec Codec) *Result {
+	result := parseEnvelope(codec, body, s.client.envelope)
+	result.client = s.client
+	return result
 }
 
 // WithToken sends a request with token to authorise
 func (s *Sender) WithToken() *Result {
+	// Refresh token before it expires
+	token := ""
+	if s.err == nil {
+		if s.client.autoRefresh {
+			if token, s.err = s.client.ensureToken(s.ctx); s.err != nil {
+				s.err = fmt.Errorf("%w: %w", ErrTokenRefresh, s.err)
+			}
+		} else {
+			token, s.err = s.client.currentToken()
+		}
+	}
+
+	return s.mapError(s.send("token", func(req *http.Request) {
+		req.Header.Set("Authorization", strings.Join([]string{s.client.tokenScheme, " ", token}, ""))
+	}, func() error {
+		if !s.client.autoRefresh {
+			s.client.logCode(s.ctx, CodeTokenExpired, LevelDebug, "permission denied, maybe token expired")
+			return ErrTokenExpired
+		}
+
+		s.client.logCode(s.ctx, CodeTokenExpired, LevelDebug, "permission denied, maybe token expired, try to renew")
+		renewed, err := s.client.renewToken(s.ctx, token)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrTokenRefresh, err)
+		}
+		token = renewed
+		return nil
+	}))
+}
+
+// WithKey sends a request with SecretID and SecretKey to authorize
+func (s *Sender) WithKey() *Result {
+	// Load secret key, zeroing the copy once the header is built
+	authorization := ""
+	if s.err == nil && s.client.authenticator == nil {
+		key, err := s.client.loadSecretKey(s.ctx)
+		switch {
+		case err != nil:
+			s.err = fmt.Errorf("failed to load secret key: %w", err)
+		case s.client.config.SecretID == "" || len(key) == 0:
+			s.err = ErrMissingCredentials // Do not send empty credentials
+		default:
+			authorization = fmt.Sprintf("%s %s:%s", s.client.keyScheme, s.client.config.SecretID, key)
+			clear(key)
+		}
+	}
+
+	return s.mapError(s.send("key", func(req *http.Request) {
+		if s.client.authenticator != nil {
+			s.client.authenticator(req)
+			return
+		}
+		req.Header.Set("Authorization", authorization)
+	}, func() error {
+		s.client.logCode(s.ctx, CodeTokenExpired, LevelDebug, "permission denied")
+		return nil
+	}))
+}
+
+// WithoutAuth sends a request without authorisation, for public endpoints
+func (s *Sender) WithoutAuth() *Result {
+	return s.mapError(s.send("no auth", func(req *http.Request) {
+		req.Header.Del("Authorization")
+	}, func() error {
+		s.client.logCode(s.ctx, CodeTokenExpired, LevelDebug, "permission denied")
+		return nil
+	}))
+}
+
+// send sends the request with retries, authorising every attempt by authorize
+// and calling denied when the upstream reports permission denied
+func (s *Sender) send(via string, authorize func(*http.Request), denied func() error) *Result {
+	// Release call timeout and pooled payload
+	if s.cancel != nil {
+		defer s.cancel()
+	}
+	defer s.pooled.done()
+
 	// Handle error
 	if s.err != nil {
 		return &Result{
@@ -108,79 +328,205 @@ func (s *Sender) WithToken() *Result {
 		}
 	}
 
-	// Copy retry delay
-	retryDelay := s.client.retryDelay
+	// Share result of the same idempotency key
+	if s.client.dedup != nil && s.idempotencyKey != "" {
+		key := s.idempotencyKey
+		s.idempotencyKey = ""
+		result := s.client.dedup.do(s.ctx, key, func() *Result {
+			return s.send(via, authorize, denied)
+		})
+		result.client = s.client
+		return result
+	}
+
+	// Report call to observer
+	if s.client.observer != nil {
+		return s.observe(func() *Result {
+			return s.sendAttempts(via, authorize, denied)
+		})
+	}
+
+	return s.sendAttempts(via, authorize, denied)
+}
 
-	for attempt := 0; attempt < s.client.maxRetries; attempt++ {
+// sendAttempts sends the request with retries for send
+func (s *Sender) sendAttempts(via string, authorize func(*http.Request), denied func() error) *Result {
+	// Load backoff strategy
+	backoff := s.client.backoffStrategy()
+	waits := 0
+	nextDelay := func() time.Duration {
+		delay := backoff.NextDelay(waits)
+		waits++
+		return delay
+	}
+
+	// Keep last non-OK transport result, last transport error and delay asked by upstream
+	var last *Result
+	var lastErr error
+	var serverDelay time.Duration
+
+	// Send only once when retry is not possible
+	maxRetries := s.client.config.MaxRetries
+	if s.noRetry {
+		maxRetries = 1
+	}
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		s.attempts++
 		if result := func() *Result {
-			// Construct client
-			client := &http.Client{
-				Timeout: time.Duration(s.client.timeout) * time.Second,
+			// Copy request, so no attempt sees headers or a drained body left by another
+			req, err := s.attempt()
+			if err != nil {
+				return &Result{
+					client: s.client,
+					Err:    err,
+				}
 			}
 
 			// Add headers
-			s.request.Header.Add("Authorization", strings.Join([]string{"Bearer ", s.client.token}, ""))
-			s.request.Header.Add("User-Agent", openapi.UserAgent)
+			override := s.client.managedHeaderOverride
+			if !override || req.Header.Get("Authorization") == "" {
+				authorize(req)
+			}
+			if !override || req.Header.Get("User-Agent") == "" {
+				req.Header.Set("User-Agent", s.client.config.UserAgent)
+			}
 
 			// Send request
-			s.client.Logger.Debug(nil, fmt.Sprintf(
-				"send request to %s, method %s with token (attempt %d)", s.request.URL, s.request.Method, attempt+1,
+			s.client.Logger.Debug(s.ctx, fmt.Sprintf(
+				"send request to %s, method %s with %s (attempt %d)", req.URL, req.Method, via, attempt+1,
 			))
-			res, err := client.Do(s.request)
+			if s.client.clientTrace {
+				trace := newRequestTrace()
+				s.trace = trace
+				req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+				defer func() {
+					s.client.Logger.Debug(s.ctx, fmt.Sprintf("request to %s traced: %s", req.URL, trace))
+				}()
+			}
+			res, err := s.client.httpClient.Do(req)
 			if err != nil {
-				s.client.Logger.Debug(nil, fmt.Sprintf("request failed: %v, retrying...", err))
+				if s.ctx.Err() != nil {
+					return &Result{
+						client: s.client,
+						Err:    err,
+					} // Stop on canceled call or exceeded deadline
+				}
+				lastErr = err
+				s.client.Logger.Debug(s.ctx, fmt.Sprintf("request failed: %v, retrying...", err))
 				return nil // Retry on network errors
 			}
+			if res.Body == nil {
+				res.Body = http.NoBody // Treat missing body as empty
+			}
 			defer func(Body io.ReadCloser) {
 				_ = Body.Close()
 			}(res.Body)
 
+			// Succeed on accepted status without reading body, which is closed unread
+			if s.headersOnly && s.client.acceptStatus[res.StatusCode] {
+				s.logResponse(res.StatusCode, CodeSuccess, nil, false)
+				return s.describe(&Result{
+					client: s.client,
+					codec:  s.codec,
+					Code:   CodeSuccess,
+					Body:   json.RawMessage("null"),
+				}, res)
+			}
+
+			// Decompress body not decompressed by transport, e.g. when Accept-Encoding is set by headers
+			if err = decompress(res); err != nil {
+				s.client.Logger.Debug(s.ctx, fmt.Sprintf("failed to decompress response body: %v, retrying...", err))
+				return nil // Retry on broken compressed body
+			}
+
+			// Decode body of other charsets to UTF-8
+			if err = transcode(res); err != nil {
+				s.client.Logger.Debug(s.ctx, fmt.Sprintf("failed to decode response body: %v", err))
+				return &Result{
+					client: s.client,
+					Err:    err,
+				}
+			}
+
 			// Handler http code error
-			if res.StatusCode != http.StatusOK {
-				s.client.Logger.Debug(nil, fmt.Sprintf("received HTTP status %d, retrying...", res.StatusCode))
+			if !s.client.acceptStatus[res.StatusCode] {
+				last = s.status(res)
+				if isRedirect(res) {
+					return last // Return redirect not followed as is
+				}
+				if res.StatusCode == http.StatusTooManyRequests || s.client.quotaCodes[last.Code] {
+					return s.quota(last) // Let caller back off on exceeded quota
+				}
+
+				// Wait as long as upstream hints, or asks during maintenance
+				if hint := s.client.retryHint(res.Header); hint > 0 {
+					serverDelay = hint
+				} else if res.StatusCode == http.StatusServiceUnavailable {
+					serverDelay = retryAfter(res.Header)
+				}
+
+				s.client.Logger.Debug(s.ctx, fmt.Sprintf("received HTTP status %d, retrying...", res.StatusCode))
 				return nil // Retry on non-200 status codes
 			}
 
 			// Get request result
-			body, err := io.ReadAll(res.Body)
+			body, err := s.client.readBody(res.Body)
+			if errors.Is(err, ErrResponseTooLarge) {
+				return s.describe(&Result{
+					client: s.client,
+					Err:    err,
+				}, res)
+			}
 			if err != nil {
-				s.client.Logger.Debug(nil, fmt.Sprintf("failed to read response body: %v, retrying...", err))
+				s.client.Logger.Debug(s.ctx, fmt.Sprintf("failed to read response body: %v, retrying...", err))
 				return nil // Retry on body read errors
 			}
 
-			// Parse result
-			parsed := s.parse(body)
+			// Parse result with the codec of response content type
+			codec := s.responseCodec(res)
+			empty := len(bytes.TrimSpace(body)) == 0
+			var parsed *Result
+			if empty {
+				// Treat empty body of accepted status as success without data
+				parsed = &Result{
+					client: s.client,
+					codec:  codec,
+					Code:   CodeSuccess,
+					Body:   json.RawMessage("null"),
+				}
+			} else {
+				parsed = s.parse(body, codec)
+			}
+			s.describe(parsed, res)
 
-			// Output log
-			var bodyRaw any
-			if err = s.client.unmarshal(body, &bodyRaw); err != nil {
-				s.client.Logger.Debug(nil, fmt.Sprintf("failed to unmarshal response body: %v, retrying...", err))
-				return nil // Retry on unmarshal errors
+			// Output log, returning decode failures as they would recur on retry
+			if parsed.Err != nil {
+				s.logResponse(res.StatusCode, parsed.Code, body, true)
+				s.client.Logger.Debug(s.ctx, fmt.Sprintf("failed to unmarshal response body: %v", parsed.Err))
+				return parsed
 			}
-			s.client.Logger.Debug(nil, fmt.Sprintf(
-				"openAPI response httpCode %d, apiCode %d, responseBody %s",
-				res.StatusCode, parsed.Code, fmt.Sprint(bodyRaw),
-			))
+			s.logResponse(res.StatusCode, parsed.Code, body, !parsed.OK())
 
 			// Check failed reason
-			if parsed.Code == 801 {
-				s.client.Logger.Debug(nil, "permission denied, maybe token expired, try to renew")
-
-				// Sleep to prevent too many requests
-				time.Sleep(time.Duration(retryDelay) * time.Second)
-
-				if s.client.exponentialBackoff {
-					retryDelay *= 2 // Exponential backoff
+			if s.client.quotaCodes[parsed.Code] {
+				return s.quota(parsed) // Let caller back off on exceeded quota
+			}
+			if parsed.Code == CodeTokenExpired {
+				if err = denied(); err != nil {
+					parsed.Err = err
+					return parsed
 				}
 
-				if err = applyToken(s.client); err != nil {
-					return &Result{
-						client: s.client,
-						Err:    err,
-					}
-				}
+				// Wait before retrying like other failures, as long as upstream hints if it does
+				last = parsed
+				serverDelay = s.client.retryHint(res.Header)
+				return nil // Retry after permission denied
+			}
 
-				return nil // Retry after token renewal
+			// Validate data of successful result
+			if parsed.OK() {
+				parsed.Err = s.client.validate(parsed)
 			}
 
 			// Return parsed result
@@ -190,133 +536,363 @@ func (s *Sender) WithToken() *Result {
 		}
 
 		// Wait before retrying
-		if attempt < s.client.maxRetries-1 {
-			s.client.Logger.Debug(nil, fmt.Sprintf("retrying in %v...", retryDelay))
-
-			time.Sleep(time.Duration(retryDelay) * time.Second)
+		if attempt < maxRetries-1 {
+			delay := nextDelay()
+			if serverDelay > 0 {
+				if err := s.client.checkRetryWait(serverDelay); err != nil {
+					if last == nil {
+						last = &Result{client: s.client}
+					}
+					last.Err = err
+					return last
+				}
+				delay, serverDelay = serverDelay, 0
+			}
+			s.client.Logger.Debug(s.ctx, fmt.Sprintf("retrying in %v...", delay))
 
-			if s.client.exponentialBackoff {
-				retryDelay *= 2 // Exponential backoff
+			if err := sleep(s.ctx, delay); err != nil {
+				return &Result{
+					client: s.client,
+					Err:    err,
+				}
 			}
 		}
 	}
 
+	// If all retries failed with a status, return it with an error
+	if last != nil && last.StatusCode == http.StatusServiceUnavailable {
+		last.Err = &ServiceUnavailableError{
+			Msg:        last.Msg,
+			RetryAfter: retryAfter(last.Header),
+		}
+		return last
+	}
+	if last != nil {
+		last.Err = fmt.Errorf(
+			"request failed after %d retries, code: %d, msg: %s", maxRetries, last.Code, last.Msg,
+		)
+		return last
+	}
+
 	// If all retries failed, return an error
+	if lastErr != nil {
+		return &Result{
+			client: s.client,
+			Err:    fmt.Errorf("request failed after %d retries: %w", maxRetries, lastErr),
+		}
+	}
 	return &Result{
 		client: s.client,
-		Err:    fmt.Errorf("request failed after %d retries", s.client.maxRetries),
+		Err:    fmt.Errorf("request failed after %d retries", maxRetries),
 	}
 }
 
-// WithKey sends a request with SecretID and SecretKey to authorize
-func (s *Sender) WithKey() *Result {
-	// Handle error
-	if s.err != nil {
-		return &Result{
-			client: s.client,
-			Err:    s.err,
-		}
+// mapError replaces the error of a failed result with the one of the error mapper
+func (s *Sender) mapError(result *Result) *Result {
+	if s.client.errorMapper == nil || result.Err == nil && result.OK() {
+		return result
 	}
 
-	// Copy retry delay
-	retryDelay := s.client.retryDelay
+	result.Err = s.client.errorMapper(result)
+	result.accepted = result.Err == nil
+	return result
+}
 
-	for attempt := 0; attempt < s.client.maxRetries; attempt++ {
-		if result := func() *Result {
-			// Construct client
-			client := &http.Client{
-				Timeout: time.Duration(s.client.timeout) * time.Second,
-			}
+// quota marks the result as exceeding quota
+func (s *Sender) quota(result *Result) *Result {
+	s.client.logCode(s.ctx, result.Code, LevelDebug, fmt.Sprintf("quota exceeded, code %d, msg %s", result.Code, result.Msg))
+	result.Err = &QuotaError{
+		Code:  result.Code,
+		Msg:   result.Msg,
+		Reset: resetTime(result.Header),
+	}
 
-			// Add headers
-			s.request.Header.Add("Authorization", fmt.Sprintf("Basic %s:%s", s.client.secretID, s.client.secretKey))
-			s.request.Header.Add("User-Agent", openapi.UserAgent)
+	return result
+}
 
-			// Send request
-			s.client.Logger.Debug(nil, fmt.Sprintf(
-				"send request to %s, method %s with key (attempt %d)", s.request.URL, s.request.Method, attempt+1,
-			))
-			res, err := client.Do(s.request)
-			if err != nil {
-				s.client.Logger.Debug(nil, fmt.Sprintf("request failed: %v, retrying...", err))
-				return nil // Retry on network errors
-			}
-			defer func(Body io.ReadCloser) {
-				_ = Body.Close()
-			}(res.Body)
+// responseCodec returns the call codec or the one of response content type
+func (s *Sender) responseCodec(res *http.Response) Codec {
+	if s.codec != nil {
+		return s.codec
+	}
 
-			// Handler http code error
-			if res.StatusCode != http.StatusOK {
-				s.client.Logger.Debug(nil, fmt.Sprintf("received HTTP status %d, retrying...", res.StatusCode))
-				return nil // Retry on non-200 status codes
-			}
+	return s.client.Codec(res.Header.Get("Content-Type"))
+}
 
-			// Get request result
-			body, err := io.ReadAll(res.Body)
-			if err != nil {
-				s.client.Logger.Debug(nil, fmt.Sprintf("failed to read response body: %v, retrying...", err))
-				return nil // Retry on body read errors
-			}
+// decompress replaces a gzip encoded response body with its decompressed content,
+// chunked transfer encoding is already removed by net/http
+func decompress(res *http.Response) error {
+	if res.Uncompressed || !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
 
-			// Parse result
-			parsed := s.parse(body)
+	reader, err := gzip.NewReader(res.Body)
+	if errors.Is(err, io.EOF) {
+		return nil // Empty body
+	}
+	if err != nil {
+		return err
+	}
 
-			// Output log
-			var bodyRaw any
-			if err = s.client.unmarshal(body, &bodyRaw); err != nil {
-				s.client.Logger.Debug(nil, fmt.Sprintf("failed to unmarshal response body: %v, retrying...", err))
-				return nil // Retry on unmarshal errors
-			}
-			s.client.Logger.Debug(nil, fmt.Sprintf(
-				"openAPI response httpCode %d, apiCode %d, responseBody %s",
-				res.StatusCode, parsed.Code, fmt.Sprint(bodyRaw),
-			))
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{reader, res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
 
-			// Check failed reason
-			if parsed.Code == 801 {
-				s.client.Logger.Debug(nil, "permission denied")
+	return nil
+}
 
-				// Sleep to prevent too many requests
-				time.Sleep(time.Duration(retryDelay) * time.Second)
+// readBody reads the response body, failing with ErrResponseTooLarge beyond the max response bytes
+func (c *Client) readBody(body io.Reader) ([]byte, error) {
+	if c.config.MaxResponseBytes <= 0 {
+		return io.ReadAll(body)
+	}
 
-				if s.client.exponentialBackoff {
-					retryDelay *= 2 // Exponential backoff
-				}
+	data, err := io.ReadAll(io.LimitReader(body, c.config.MaxResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.config.MaxResponseBytes {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, c.config.MaxResponseBytes)
+	}
 
-				return nil // Retry after token renewal
-			}
+	return data, nil
+}
 
-			// Return parsed result
-			return parsed
-		}(); result != nil {
-			return result
+// attempt returns a fresh copy of the request with a rewound body for one attempt
+func (s *Sender) attempt() (*http.Request, error) {
+	req := s.request.Clone(s.ctx)
+	if s.request.GetBody != nil {
+		body, err := s.request.GetBody()
+		if err != nil {
+			return nil, err
 		}
+		req.Body = body
+	}
 
-		// Wait before retrying
-		if attempt < s.client.maxRetries-1 {
-			s.client.Logger.Debug(nil, fmt.Sprintf("retrying in %v...", retryDelay))
+	// Report upload progress
+	if s.uploadProgress != nil && req.Body != nil && req.Body != http.NoBody {
+		total := req.ContentLength
+		if total <= 0 {
+			total = -1
+		}
+		req.Body = &progressBody{
+			ReadCloser: req.Body,
+			total:      total,
+			progress:   s.uploadProgress,
+		}
+	}
 
-			time.Sleep(time.Duration(retryDelay) * time.Second)
+	return req, nil
+}
 
-			if s.client.exponentialBackoff {
-				retryDelay *= 2 // Exponential backoff
-			}
+// logResponse logs the response, with the request and response bodies as allowed by the log policy
+func (s *Sender) logResponse(statusCode int, code int, body []byte, failed bool) {
+	switch {
+	case s.client.logPolicy == LogBodyAlways, s.client.logPolicy == LogBodyOnError && failed:
+		if s.client.logRequestBody && s.payload != nil {
+			s.client.logCode(s.ctx, code, LevelDebug, fmt.Sprintf(
+				"openAPI response httpCode %d, apiCode %d, requestBody %s, responseBody %s",
+				statusCode, code, s.client.logBody([]byte(redactBody(s.payload))), s.client.logBody(body),
+			))
+			return
 		}
+		s.client.logCode(s.ctx, code, LevelDebug, fmt.Sprintf(
+			"openAPI response httpCode %d, apiCode %d, responseBody %s", statusCode, code, s.client.logBody(body),
+		))
+	default:
+		s.client.logCode(s.ctx, code, LevelDebug, fmt.Sprintf(
+			"openAPI response httpCode %d, apiCode %d", statusCode, code,
+		))
 	}
+}
 
-	// If all retries failed, return an error
-	return &Result{
+// isRedirect reports whether the response is a redirect not followed
+func isRedirect(res *http.Response) bool {
+	return res.StatusCode >= 300 && res.StatusCode < 400 && res.Header.Get("Location") != ""
+}
+
+// describe fills the http status, final URL after redirects, headers and traced timings of the
+// response into result
+func (s *Sender) describe(result *Result, res *http.Response) *Result {
+	result.StatusCode = res.StatusCode
+	result.Header = res.Header
+	if res.Request != nil {
+		result.URL = res.Request.URL.String()
+	}
+	if s.trace != nil {
+		result.Timings = s.trace.timings()
+	}
+
+	return result
+}
+
+// status builds a result for a non-OK http response, preferring the upstream envelope when present
+func (s *Sender) status(res *http.Response) *Result {
+	// Fall back to http status
+	result := s.describe(&Result{
 		client: s.client,
-		Err:    fmt.Errorf("request failed after %d retries", s.client.maxRetries),
+		Code:   res.StatusCode,
+		Msg:    http.StatusText(res.StatusCode),
+	}, res)
+
+	// Try to parse envelope body
+	body, err := s.client.readBody(res.Body)
+	if err != nil || len(body) == 0 {
+		return result
 	}
+	s.logResponse(res.StatusCode, 0, body, true)
+	parsed := s.parse(body, s.responseCodec(res))
+	if parsed.Err != nil || parsed.Code == 0 {
+		return result
+	}
+
+	// Keep server-provided message
+	s.describe(parsed, res)
+	if parsed.Msg == "" {
+		parsed.Msg = result.Msg
+	}
+
+	return parsed
 }
 
 // OK returns a bool value stands for the success or not of the request
 func (r *Result) OK() bool {
-	return r.Code == 200
+	return r.Code == CodeSuccess
+}
+
+// IsUnauthorized reports whether upstream rejected the credentials, by HTTP status or API code
+func (r *Result) IsUnauthorized() bool {
+	return r.StatusCode == http.StatusUnauthorized || r.Code == CodeUnauthorized
+}
+
+// IsNotFound reports whether upstream found nothing, by HTTP status or API code
+func (r *Result) IsNotFound() bool {
+	return r.StatusCode == http.StatusNotFound || r.Code == CodeNotFound
+}
+
+// IsRateLimited reports whether upstream limited the rate or quota, by HTTP status or API code,
+// including codes set by WithQuotaCodes
+func (r *Result) IsRateLimited() bool {
+	if r.StatusCode == http.StatusTooManyRequests || r.Code == CodeQuotaExceeded {
+		return true
+	}
+
+	return r.client != nil && r.client.quotaCodes[r.Code]
+}
+
+// IsServerError reports whether upstream failed to serve, by HTTP status or API code
+func (r *Result) IsServerError() bool {
+	return r.StatusCode >= http.StatusInternalServerError || r.Code == CodeServerError
+}
+
+// AsError returns the error of result, an *APIError when upstream did not succeed, or nil
+func (r *Result) AsError() error {
+	return r.Expect()
+}
+
+// Expect returns the error of result, an *APIError when code is not one of codes, or nil,
+// codes defaults to success. A result accepted by the error mapper is always expected.
+func (r *Result) Expect(codes ...int) error {
+	if r.Err != nil {
+		return r.Err
+	}
+
+	// Check code
+	if r.accepted || len(codes) == 0 && r.OK() || slices.Contains(codes, r.Code) {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: r.StatusCode,
+		Code:       r.Code,
+		Msg:        r.Msg,
+	}
+	if r.client != nil {
+		apiErr.RetryAfter = r.client.retryHint(r.Header)
+	}
+
+	return apiErr
+}
+
+// RequireOK returns the error of result, an *APIError unless upstream succeeded as reported by OK,
+// or nil. As with Expect, a result accepted by the error mapper passes.
+func (r *Result) RequireOK() error {
+	return r.Expect(CodeSuccess)
+}
+
+// DecodeOK checks upstream succeeded as RequireOK does, then reads the ok field of the data, for
+// endpoints reporting their outcome in it. A missing ok field reads as false.
+func (r *Result) DecodeOK() (bool, error) {
+	if err := r.RequireOK(); err != nil {
+		return false, err
+	}
+
+	var data struct {
+		Ok bool `json:"ok"`
+	}
+	if err := r.DecodeLenient(&data); err != nil {
+		return false, err
+	}
+
+	return data.Ok, nil
+}
+
+// HasData reports whether the response carries data, as opposed to null or missing data
+func (r *Result) HasData() bool {
+	body := bytes.TrimSpace(r.Body)
+	return len(body) > 0 && !bytes.Equal(body, []byte("null"))
+}
+
+// DecodeInto decodes the data part of the response envelope into v, as extracted when parsing the
+// response, not the whole response body, see RawEnvelope for that. Null or missing data resets v to
+// its zero value without error, e.g. an empty struct or a nil pointer, see HasData. Failures are
+// reported as a *DecodeError carrying the request URL. Unknown fields fail with WithStrictDecoding.
+func (r *Result) DecodeInto(v any) error {
+	return r.decodeInto(v, r.client != nil && r.client.strictDecoding)
+}
+
+// DecodeLenient decodes the data part of the response envelope into v as DecodeInto does, ignoring
+// WithStrictDecoding, for targets reading only part of the data, e.g. in service packages
+func (r *Result) DecodeLenient(v any) error {
+	return r.decodeInto(v, false)
+}
+
+// decodeInto decodes the data part of the response envelope into v, disallowing unknown fields if strict
+func (r *Result) decodeInto(v any, strict bool) error {
+	// Reset target on no data
+	if !r.HasData() {
+		if target := reflect.ValueOf(v); target.Kind() == reflect.Pointer && !target.IsNil() {
+			target.Elem().SetZero()
+			return nil
+		}
+	}
+
+	// Load codec of response
+	codec := r.codec
+	if codec == nil {
+		codec = r.client.Codec(ContentTypeJSON)
+	}
+	if strict {
+		codec = strictCodec(codec)
+	}
+
+	if err := unmarshal(codec, r.Body, v); err != nil {
+		decodeErr := newDecodeError(r.Body, v, err)
+		decodeErr.URL = r.URL
+		return decodeErr
+	}
+
+	return nil
 }
 
-// Unmarshal can unmarshal a request data body to customised struct
+// Unmarshal decodes the data part of the response envelope into v.
+//
+// Deprecated: Use DecodeInto, which it calls, as Unmarshal reads as if it decoded the whole response.
 func (r *Result) Unmarshal(v any) error {
-	return r.client.unmarshal(r.Body, v)
+	return r.DecodeInto(v)
 }
@@ -0,0 +1,457 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSingleManagedHeaders(t *testing.T) {
+	var mu sync.Mutex
+	var headers []http.Header
+	var calls atomic.Int32
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Clone())
+		mu.Unlock()
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	})
+	c := newTestClient(t, server,
+		WithUserAgent("test-agent"),
+		WithDefaultHeaders(http.Header{"Content-Type": {"text/plain"}}),
+	)
+
+	result := c.Send(c.URL("/data"), http.MethodPost, map[string]string{"a": "b"},
+		WithHeader("Content-Type", ContentTypeJSON),
+		WithHeader("User-Agent", "caller-agent"),
+	).WithToken()
+	if result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(headers) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(headers))
+	}
+	for i, header := range headers {
+		for name, want := range map[string]string{
+			"Content-Type":  ContentTypeJSON,
+			"Authorization": "Bearer " + testToken,
+			"User-Agent":    "test-agent",
+		} {
+			if got := header.Values(name); len(got) != 1 || got[0] != want {
+				t.Errorf("attempt %d: %s = %q, want single %q", i+1, name, got, want)
+			}
+		}
+	}
+}
+
+func TestWithoutAuth(t *testing.T) {
+	var authorization atomic.Value
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Values("Authorization"))
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	})
+	c := newTestClient(t, server, WithDefaultHeaders(http.Header{"Authorization": {"Bearer default"}}))
+
+	if result := c.Send(c.URL("/public"), http.MethodGet, nil).WithoutAuth(); result.Err != nil {
+		t.Fatalf("WithoutAuth() error = %v", result.Err)
+	}
+	if got := authorization.Load().([]string); len(got) != 0 {
+		t.Errorf("Authorization = %q, want none", got)
+	}
+}
+
+func TestRawPayloads(t *testing.T) {
+	const raw = `{"a":"<b>"}`
+	tests := []struct {
+		name    string
+		payload any
+	}{
+		{"bytes", []byte(raw)},
+		{"raw message", json.RawMessage(raw)},
+		{"reader", strings.NewReader(raw)},
+		{"unseekable reader", io.MultiReader(strings.NewReader(raw))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body atomic.Value
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				body.Store(string(data))
+				writeEnvelope(w, CodeSuccess, "ok", "null")
+			})
+			c := newTestClient(t, server)
+
+			if result := c.Send(c.URL("/data"), http.MethodPost, tt.payload).WithToken(); result.Err != nil {
+				t.Fatalf("Send() error = %v", result.Err)
+			}
+			if got := body.Load(); got != raw {
+				t.Errorf("body = %v, want %s", got, raw)
+			}
+		})
+	}
+}
+
+func TestAcceptStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		data     string
+		wantData bool
+	}{
+		{"created with body", http.StatusCreated, `{"id":"a"}`, true},
+		{"no content", http.StatusNoContent, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.data == "" {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Header().Set("Content-Type", ContentTypeJSON)
+				w.WriteHeader(tt.status)
+				_, _ = fmt.Fprintf(w, `{"code":%d,"msg":"ok","data":%s}`, CodeSuccess, tt.data)
+			})
+			c := newTestClient(t, server, WithAcceptStatus(http.StatusCreated, http.StatusNoContent))
+
+			result := c.Send(c.URL("/data"), http.MethodPost, nil).WithToken()
+			if result.Err != nil || !result.OK() {
+				t.Fatalf("Send() = %d, error = %v, want success", result.Code, result.Err)
+			}
+			if result.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", result.StatusCode, tt.status)
+			}
+			if result.HasData() != tt.wantData {
+				t.Errorf("HasData() = %v, want %v", result.HasData(), tt.wantData)
+			}
+		})
+	}
+
+	t.Run("not accepted", func(t *testing.T) {
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+		c := newTestClient(t, server, WithMaxRetries(2))
+
+		if result := c.Send(c.URL("/data"), http.MethodPost, nil).WithToken(); result.Err == nil {
+			t.Errorf("Send() error = nil, want error of status %d", http.StatusCreated)
+		}
+	})
+}
+
+// roundTripperFunc is an http.RoundTripper of a function
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNilResponseBody(t *testing.T) {
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == TokenEndpoint {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {ContentTypeJSON}},
+				Body: io.NopCloser(strings.NewReader(fmt.Sprintf(
+					`{"code":%d,"msg":"ok","data":{"token":%q}}`, CodeSuccess, testToken,
+				))),
+				Request: req,
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Request:    req,
+		}, nil
+	})
+	c, err := NewClient("id", "key",
+		WithEndpoint("http://upstream.test"),
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithLogger(NewLogger(WithLoggerOutput(io.Discard))),
+		WithRetryDelay(0),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	for name, send := range map[string]func(*Sender) *Result{
+		"token": (*Sender).WithToken,
+		"key":   (*Sender).WithKey,
+	} {
+		t.Run(name, func(t *testing.T) {
+			result := send(c.Send(c.URL("/data"), http.MethodHead, nil))
+			if result.Err != nil || !result.OK() || result.HasData() {
+				t.Errorf("Send() = %d, error = %v, want success without data", result.Code, result.Err)
+			}
+		})
+	}
+}
+
+func TestGetPayload(t *testing.T) {
+	tests := []struct {
+		name            string
+		payload         any
+		options         []CallOption
+		wantContentType string
+		wantBody        string
+		wantQuery       string
+	}{
+		{"body", map[string]string{"a": "b"}, nil, ContentTypeJSON, `{"a":"b"}`, ""},
+		{"query", nil, []CallOption{WithQuery(url.Values{"a": {"b"}})}, "", "", "a=b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got atomic.Value
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				got.Store([]string{r.Header.Get("Content-Type"), string(body), r.URL.RawQuery})
+				writeEnvelope(w, CodeSuccess, "ok", "null")
+			})
+			c := newTestClient(t, server)
+
+			if result := c.Send(c.URL("/data"), http.MethodGet, tt.payload, tt.options...).WithToken(); result.Err != nil {
+				t.Fatalf("Send() error = %v", result.Err)
+			}
+			request := got.Load().([]string)
+			if request[0] != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", request[0], tt.wantContentType)
+			}
+			if request[1] != tt.wantBody {
+				t.Errorf("body = %q, want %q", request[1], tt.wantBody)
+			}
+			if request[2] != tt.wantQuery {
+				t.Errorf("query = %q, want %q", request[2], tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestAuthorizationSchemes(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []Option
+		wantToken string
+		wantKey   string
+	}{
+		{"default", nil, "Bearer " + testToken, "Basic id:key"},
+		{"custom", []Option{WithTokenScheme("GhinkToken"), WithKeyScheme("GhinkKey")},
+			"GhinkToken " + testToken, "GhinkKey id:key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var key, token atomic.Value
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == TokenEndpoint {
+					key.Store(r.Header.Get("Authorization"))
+					writeEnvelope(w, CodeSuccess, "ok", fmt.Sprintf(`{"token":%q}`, testToken))
+					return
+				}
+				token.Store(r.Header.Get("Authorization"))
+				writeEnvelope(w, CodeSuccess, "ok", "null")
+			}))
+			t.Cleanup(server.Close)
+			c := newTestClient(t, server, tt.options...)
+
+			if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken(); result.Err != nil {
+				t.Fatalf("WithToken() error = %v", result.Err)
+			}
+			if got := key.Load(); got != tt.wantKey {
+				t.Errorf("key Authorization = %v, want %s", got, tt.wantKey)
+			}
+			if got := token.Load(); got != tt.wantToken {
+				t.Errorf("token Authorization = %v, want %s", got, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestNoRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []CallOption
+		wantCalls int32
+	}{
+		{"retried", nil, 3},
+		{"no retry", []CallOption{WithNoRetry()}, 1},
+		{"no retry with idempotency key", []CallOption{WithNoRetry(), WithIdempotencyKey("key")}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+			c := newTestClient(t, server, WithMaxRetries(3))
+
+			if result := c.Send(c.URL("/data"), http.MethodPost, nil, tt.options...).WithToken(); result.Err == nil {
+				t.Fatal("Send() error = nil, want error")
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHeaderFromContext(t *testing.T) {
+	type traceKey struct{}
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		options []CallOption
+		want    string
+	}{
+		{"present", context.WithValue(context.Background(), traceKey{}, "trace-1"), nil, "trace-1"},
+		{"absent", context.Background(), nil, "default"},
+		{"call header", context.WithValue(context.Background(), traceKey{}, "trace-1"),
+			[]CallOption{WithHeader("X-Trace-ID", "call")}, "call"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got atomic.Value
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				got.Store(r.Header.Values("X-Trace-ID"))
+				writeEnvelope(w, CodeSuccess, "ok", "null")
+			})
+			c := newTestClient(t, server,
+				WithDefaultHeaders(http.Header{"X-Trace-ID": {"default"}}),
+				WithHeaderFromContext("X-Trace-ID", traceKey{}),
+			)
+
+			options := append([]CallOption{WithContext(tt.ctx)}, tt.options...)
+			if result := c.Send(c.URL("/data"), http.MethodGet, nil, options...).WithToken(); result.Err != nil {
+				t.Fatalf("Send() error = %v", result.Err)
+			}
+			if values := got.Load().([]string); len(values) != 1 || values[0] != tt.want {
+				t.Errorf("X-Trace-ID = %q, want single %q", values, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryWithPayload(t *testing.T) {
+	var got atomic.Value
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got.Store([]string{r.URL.Query().Encode(), string(body)})
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	})
+	c := newTestClient(t, server)
+
+	result := c.Send(c.URL("/data")+"?a=1", http.MethodPost, map[string]string{"name": "x"},
+		WithQuery(url.Values{"a": {"2"}, "b": {"3"}}),
+	).WithToken()
+	if result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+	request := got.Load().([]string)
+	if request[0] != "a=1&a=2&b=3" {
+		t.Errorf("query = %s, want a=1&a=2&b=3", request[0])
+	}
+	if request[1] != `{"name":"x"}` {
+		t.Errorf("body = %s, want {\"name\":\"x\"}", request[1])
+	}
+}
+
+func TestWithHost(t *testing.T) {
+	var mu sync.Mutex
+	hosts := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hosts[r.URL.Path] = r.Host
+		mu.Unlock()
+		if r.URL.Path == TokenEndpoint {
+			writeEnvelope(w, CodeSuccess, "ok", fmt.Sprintf(`{"token":%q}`, testToken))
+			return
+		}
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, server, WithHost("api.example.com"))
+
+	if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken(); result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range []string{TokenEndpoint, "/data"} {
+		if hosts[path] != "api.example.com" {
+			t.Errorf("%s: Host = %q, want api.example.com", path, hosts[path])
+		}
+	}
+}
+
+func TestAcceptHeader(t *testing.T) {
+	var mu sync.Mutex
+	accepts := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		accepts[r.URL.Path] = r.Header.Values("Accept")
+		mu.Unlock()
+		if r.URL.Path == TokenEndpoint {
+			writeEnvelope(w, CodeSuccess, "ok", fmt.Sprintf(`{"token":%q}`, testToken))
+			return
+		}
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	}))
+	t.Cleanup(server.Close)
+	c := newTestClient(t, server)
+
+	if result := c.Send(c.URL("/data"), http.MethodPost, map[string]string{"a": "b"}).WithToken(); result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range []string{TokenEndpoint, "/data"} {
+		if got := accepts[path]; len(got) != 1 || got[0] != ContentTypeJSON {
+			t.Errorf("%s: Accept = %q, want single %s", path, got, ContentTypeJSON)
+		}
+	}
+}
+
+func TestSendAbsoluteURL(t *testing.T) {
+	var endpointCalls atomic.Int32
+	endpoint := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		endpointCalls.Add(1)
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	})
+	var authorization atomic.Value
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	}))
+	t.Cleanup(other.Close)
+	c := newTestClient(t, endpoint)
+
+	if result := c.Send(other.URL+"/data", http.MethodGet, nil).WithToken(); result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+	if got := endpointCalls.Load(); got != 0 {
+		t.Errorf("endpoint got %d data calls, want none", got)
+	}
+	if got := authorization.Load(); got != "Bearer "+testToken {
+		t.Errorf("Authorization = %v, want Bearer %s", got, testToken)
+	}
+}
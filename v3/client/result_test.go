@@ -0,0 +1,198 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// newTestResult returns a result of code and data decoded by the default JSON codec
+func newTestResult(code int, data string) *Result {
+	return &Result{
+		codec: jsonCodec{unmarshal: unmarshalJSON},
+		Code:  code,
+		Body:  json.RawMessage(data),
+	}
+}
+
+func TestRequireOK(t *testing.T) {
+	if err := newTestResult(CodeSuccess, "null").RequireOK(); err != nil {
+		t.Errorf("RequireOK() error = %v, want nil", err)
+	}
+
+	var apiErr *APIError
+	if err := newTestResult(CodeServerError, "null").RequireOK(); !errors.As(err, &apiErr) || apiErr.Code != CodeServerError {
+		t.Errorf("RequireOK() error = %v, want *APIError of code %d", err, CodeServerError)
+	}
+
+	sendErr := errors.New("send failed")
+	if err := (&Result{Err: sendErr}).RequireOK(); !errors.Is(err, sendErr) {
+		t.Errorf("RequireOK() error = %v, want %v", err, sendErr)
+	}
+}
+
+func TestDecodeOK(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		data    string
+		want    bool
+		wantErr bool
+	}{
+		{"ok", CodeSuccess, `{"ok":true}`, true, false},
+		{"not ok", CodeSuccess, `{"ok":false}`, false, false},
+		{"ok missing", CodeSuccess, `{}`, false, false},
+		{"no data", CodeSuccess, `null`, false, false},
+		{"malformed ok", CodeSuccess, `{"ok":1}`, false, true},
+		{"upstream failed", CodeForbidden, `{"ok":true}`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := newTestResult(tt.code, tt.data).DecodeOK()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeOK() error = %v, want error %v", err, tt.wantErr)
+			}
+			if ok != tt.want {
+				t.Errorf("DecodeOK() = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeIntoPrimitiveData(t *testing.T) {
+	t.Run("array", func(t *testing.T) {
+		var got []int
+		if err := newTestResult(CodeSuccess, `[1,2,3]`).DecodeInto(&got); err != nil {
+			t.Fatalf("DecodeInto() error = %v", err)
+		}
+		if len(got) != 3 || got[0] != 1 || got[2] != 3 {
+			t.Errorf("DecodeInto() = %v, want [1 2 3]", got)
+		}
+	})
+	t.Run("string", func(t *testing.T) {
+		var got string
+		if err := newTestResult(CodeSuccess, `"hello"`).DecodeInto(&got); err != nil {
+			t.Fatalf("DecodeInto() error = %v", err)
+		}
+		if got != "hello" {
+			t.Errorf("DecodeInto() = %q, want %q", got, "hello")
+		}
+	})
+	t.Run("number", func(t *testing.T) {
+		var got int
+		if err := newTestResult(CodeSuccess, `42`).DecodeInto(&got); err != nil {
+			t.Fatalf("DecodeInto() error = %v", err)
+		}
+		if got != 42 {
+			t.Errorf("DecodeInto() = %d, want 42", got)
+		}
+	})
+}
+
+func TestSendPrimitiveData(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, CodeSuccess, "ok", `["a","b"]`)
+	})
+	c := newTestClient(t, server)
+
+	var got []string
+	if err := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken().DecodeInto(&got); err != nil {
+		t.Fatalf("DecodeInto() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("DecodeInto() = %v, want [a b]", got)
+	}
+}
+
+func TestDecodeIntoNullData(t *testing.T) {
+	type data struct {
+		ID string `json:"id"`
+	}
+	for _, body := range []string{"null", ""} {
+		t.Run("body "+body, func(t *testing.T) {
+			result := newTestResult(CodeSuccess, body)
+			if result.HasData() {
+				t.Error("HasData() = true, want false")
+			}
+
+			value := data{ID: "stale"}
+			if err := result.DecodeInto(&value); err != nil || value != (data{}) {
+				t.Errorf("DecodeInto(struct) = %+v, error = %v, want zero value", value, err)
+			}
+
+			pointer := &data{ID: "stale"}
+			if err := result.DecodeInto(&pointer); err != nil || pointer != nil {
+				t.Errorf("DecodeInto(pointer) = %+v, error = %v, want nil", pointer, err)
+			}
+		})
+	}
+}
+
+func TestResultPredicates(t *testing.T) {
+	quotaClient := &Client{quotaCodes: map[int]bool{4290: true}}
+	tests := []struct {
+		name             string
+		result           *Result
+		wantUnauthorized bool
+		wantNotFound     bool
+		wantRateLimited  bool
+		wantServerError  bool
+	}{
+		{"success", &Result{StatusCode: http.StatusOK, Code: CodeSuccess}, false, false, false, false},
+		{"unauthorized status", &Result{StatusCode: http.StatusUnauthorized}, true, false, false, false},
+		{"unauthorized code", &Result{StatusCode: http.StatusOK, Code: CodeUnauthorized}, true, false, false, false},
+		{"not found status", &Result{StatusCode: http.StatusNotFound}, false, true, false, false},
+		{"not found code", &Result{StatusCode: http.StatusOK, Code: CodeNotFound}, false, true, false, false},
+		{"rate limited status", &Result{StatusCode: http.StatusTooManyRequests}, false, false, true, false},
+		{"rate limited code", &Result{StatusCode: http.StatusOK, Code: CodeQuotaExceeded}, false, false, true, false},
+		{"quota code", &Result{client: quotaClient, StatusCode: http.StatusOK, Code: 4290}, false, false, true, false},
+		{"server error status", &Result{StatusCode: http.StatusBadGateway}, false, false, false, true},
+		{"server error code", &Result{StatusCode: http.StatusOK, Code: CodeServerError}, false, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.IsUnauthorized(); got != tt.wantUnauthorized {
+				t.Errorf("IsUnauthorized() = %v, want %v", got, tt.wantUnauthorized)
+			}
+			if got := tt.result.IsNotFound(); got != tt.wantNotFound {
+				t.Errorf("IsNotFound() = %v, want %v", got, tt.wantNotFound)
+			}
+			if got := tt.result.IsRateLimited(); got != tt.wantRateLimited {
+				t.Errorf("IsRateLimited() = %v, want %v", got, tt.wantRateLimited)
+			}
+			if got := tt.result.IsServerError(); got != tt.wantServerError {
+				t.Errorf("IsServerError() = %v, want %v", got, tt.wantServerError)
+			}
+		})
+	}
+}
+
+func TestDecodeIntoError(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, CodeSuccess, "ok", `{"id":1}`)
+	})
+	c := newTestClient(t, server)
+	result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+	if result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+
+	var data struct {
+		ID string `json:"id"`
+	}
+	for name, decode := range map[string]func(any) error{
+		"DecodeInto": result.DecodeInto,
+		"Unmarshal":  result.Unmarshal,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var decodeErr *DecodeError
+			if err := decode(&data); !errors.As(err, &decodeErr) {
+				t.Fatalf("%s() error = %v, want *DecodeError", name, err)
+			}
+			if decodeErr.URL != c.URL("/data") || decodeErr.Snippet != `{"id":1}` {
+				t.Errorf("DecodeError = %+v, want URL %s and snippet of data", decodeErr, c.URL("/data"))
+			}
+		})
+	}
+}
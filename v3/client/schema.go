@@ -0,0 +1,82 @@
+package client
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+)
+
+// ResponseValidator validates the raw data of successful responses, e.g. against a JSON schema,
+// see the validator/jsonschema module for one
+type ResponseValidator interface {
+	Validate(data []byte) error
+}
+
+// ResponseValidatorFunc adapts a function to ResponseValidator
+type ResponseValidatorFunc func(data []byte) error
+
+// Validate calls f
+func (f ResponseValidatorFunc) Validate(data []byte) error {
+	return f(data)
+}
+
+// SchemaError provides the path of a response whose data does not match its schema
+type SchemaError struct {
+	Path string
+	Err  error
+}
+
+// Error returns the error message
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("response data of %s does not match schema: %s", e.Path, e.Err)
+}
+
+// Unwrap returns the validation error
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// WithResponseValidator sets a validator the data of successful responses of the endpoint path,
+// e.g. "/shortLink/add", is checked by, failing with *SchemaError on mismatch.
+// Validation usually costs a decode of the data, so it is opt-in.
+func WithResponseValidator(path string, validator ResponseValidator) Option {
+	return func(c *Client) {
+		// Copy validators shared with clones
+		validators := maps.Clone(c.validators)
+		if validators == nil {
+			validators = make(map[string]ResponseValidator, 1)
+		}
+		validators[path] = validator
+		c.validators = validators
+	}
+}
+
+// validate checks the data of result by the validator of its endpoint path, if any
+func (c *Client) validate(result *Result) error {
+	if len(c.validators) == 0 || result.URL == "" {
+		return nil
+	}
+
+	// Find validator of endpoint path
+	resultURL, err := url.Parse(result.URL)
+	if err != nil {
+		return nil
+	}
+	for path, validator := range c.validators {
+		endpointURL, err := url.Parse(c.URL(path))
+		if err != nil || endpointURL.Path != resultURL.Path {
+			continue
+		}
+
+		// Validate data
+		if err = validator.Validate(result.Body); err != nil {
+			return &SchemaError{
+				Path: path,
+				Err:  err,
+			}
+		}
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestResponseValidator(t *testing.T) {
+	errMismatch := errors.New("linkID missing")
+	validator := ResponseValidatorFunc(func(data []byte) error {
+		if !bytes.Contains(data, []byte(`"linkID"`)) {
+			return errMismatch
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		code    int
+		data    string
+		wantErr bool
+	}{
+		{"match", "/shortLink/add", CodeSuccess, `{"linkID":"abc"}`, false},
+		{"mismatch", "/shortLink/add", CodeSuccess, `{}`, true},
+		{"other path", "/shortLink/resolve", CodeSuccess, `{}`, false},
+		{"failed result", "/shortLink/add", CodeServerError, `{}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				writeEnvelope(w, tt.code, "msg", tt.data)
+			})
+			c := newTestClient(t, server, WithResponseValidator("/shortLink/add", validator))
+
+			result := c.Send(c.URL(tt.path), http.MethodGet, nil, WithNoRetry()).WithToken()
+			var schemaErr *SchemaError
+			if got := errors.As(result.Err, &schemaErr); got != tt.wantErr {
+				t.Fatalf("Send() error = %v, want SchemaError %v", result.Err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+			if schemaErr.Path != "/shortLink/add" {
+				t.Errorf("SchemaError.Path = %q, want %q", schemaErr.Path, "/shortLink/add")
+			}
+			if !errors.Is(result.Err, errMismatch) {
+				t.Errorf("Send() error = %v, want it to wrap %v", result.Err, errMismatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+)
+
+// SecretProvider provides the secret key on demand, e.g. from a file, Vault or KMS, so it is not
+// held by the client. The returned slice is zeroed after use.
+type SecretProvider interface {
+	SecretKey(ctx context.Context) ([]byte, error)
+}
+
+// SecretProviderFunc adapts a function to SecretProvider
+type SecretProviderFunc func(ctx context.Context) ([]byte, error)
+
+// SecretKey calls f
+func (f SecretProviderFunc) SecretKey(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// WithSecretProvider sets the provider consulted for the secret key at construction and on
+// every request authorised with it, including token refresh, in place of the secretKey argument
+func WithSecretProvider(provider SecretProvider) Option {
+	return func(c *Client) {
+		c.secretProvider = provider
+		c.config.SecretKeyFile = ""
+	}
+}
+
+// WithSecretKeyFile sets the secret key to be read from the file at path on demand,
+// ignoring surrounding whitespace, so rotated keys are picked up without restart
+func WithSecretKeyFile(path string) Option {
+	return func(c *Client) {
+		c.config.SecretKeyFile = path
+		c.secretProvider = nil
+	}
+}
+
+// fileSecretProvider returns the provider reading the secret key file at path
+func fileSecretProvider(path string) SecretProvider {
+	return SecretProviderFunc(func(ctx context.Context) ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret key file: %w", err)
+		}
+
+		// Trim whitespace in place so the whole buffer is zeroed after use
+		key := bytes.TrimSpace(data)
+		n := copy(data, key)
+		clear(data[n:])
+		return data[:n], nil
+	})
+}
+
+// loadSecretKey returns the secret key from the provider, or the one the client holds
+func (c *Client) loadSecretKey(ctx context.Context) ([]byte, error) {
+	if c.secretProvider == nil {
+		return []byte(c.config.SecretKey), nil
+	}
+
+	key, err := c.secretProvider.SecretKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, ErrMissingCredentials
+	}
+
+	return key, nil
+}
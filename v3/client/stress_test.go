@@ -0,0 +1,89 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+	"go.gh.ink/openapi/sdk/20260422/v3/private/realName"
+	"go.gh.ink/openapi/sdk/20260422/v3/public/shortLink"
+)
+
+// writeEnvelope writes a response envelope of code, msg and data
+func writeEnvelope(w http.ResponseWriter, code int, msg string, data string) {
+	w.Header().Set("Content-Type", client.ContentTypeJSON)
+	_, _ = fmt.Fprintf(w, `{"code":%d,"msg":%q,"data":%s}`, code, msg, data)
+}
+
+func TestConcurrentServices(t *testing.T) {
+	var tokens atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == client.TokenEndpoint:
+			writeEnvelope(w, client.CodeSuccess, "ok", fmt.Sprintf(`{"token":"token-%d"}`, tokens.Add(1)))
+		case len(r.Header.Values("Authorization")) != 1 || !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer token-"):
+			writeEnvelope(w, client.CodeTokenExpired, "bad authorization", "null")
+		case r.URL.Path == shortLink.Endpoint+"/add":
+			writeEnvelope(w, client.CodeSuccess, "ok", `{"linkID":"abc"}`)
+		case r.URL.Path == realName.Endpoint+"/cnid":
+			writeEnvelope(w, client.CodeSuccess, "ok", `{"ok":true}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := client.NewClient("id", "key",
+		client.WithEndpoint(server.URL),
+		client.WithLogger(client.NewLogger(client.WithLoggerOutput(io.Discard))),
+		client.WithRetryDelay(0),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	const workers, rounds = 8, 20
+	validity := time.Now().Add(time.Hour)
+	errs := make(chan error, 3*workers*rounds)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for range rounds {
+				if linkID, err := shortLink.Add(c, "https://example.com", &validity); err != nil || linkID != "abc" {
+					errs <- fmt.Errorf("Add() = %q, error = %v", linkID, err)
+				}
+			}
+		})
+		wg.Go(func() {
+			for range rounds {
+				if ok, err := realName.VerifyCNID(c, "11010519491231002X", "name"); err != nil || !ok {
+					errs <- fmt.Errorf("VerifyCNID() = %v, error = %v", ok, err)
+				}
+			}
+		})
+		wg.Go(func() {
+			for range rounds {
+				if err := c.RefreshToken(context.Background()); err != nil {
+					errs <- fmt.Errorf("RefreshToken() error = %v", err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,307 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenState provides token state shared by a client, guarded by the refresh mutex
+type tokenState struct {
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	offset time.Duration
+	jitter time.Duration
+	scopes []string
+	rng    *rand.Rand
+}
+
+// newTokenState creates token state drawing jitter from a randomly seeded source
+func newTokenState() *tokenState {
+	return &tokenState{
+		rng: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+// WithExpirySkew sets how long before the token expiry it gets refreshed
+func WithExpirySkew(expirySkew time.Duration) Option {
+	return func(c *Client) {
+		c.expirySkew = expirySkew
+	}
+}
+
+// WithClockSkew sets the tolerated difference between the local and server clock.
+// The server clock is estimated from the Date header of the token response, and
+// the token is refreshed once the estimated server time plus expiry skew and clock
+// skew reaches the token expiry, so both margins add up.
+func WithClockSkew(clockSkew time.Duration) Option {
+	return func(c *Client) {
+		c.clockSkew = clockSkew
+	}
+}
+
+// WithAutoRefresh sets whether token is refreshed automatically, when disabled requests with an
+// expired token fail with ErrTokenExpired and the token is only refreshed by RefreshToken
+func WithAutoRefresh(autoRefresh bool) Option {
+	return func(c *Client) {
+		c.autoRefresh = autoRefresh
+	}
+}
+
+// RefreshToken gets a new token regardless of the current one
+func (c *Client) RefreshToken(ctx context.Context) error {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	return applyToken(ctx, c)
+}
+
+// TokenValid reports whether the client holds a token that does not need refreshing yet
+func (c *Client) TokenValid() bool {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	return !c.tokenExpired()
+}
+
+// TokenScopes returns the scopes of the token reported by upstream, empty when not reported
+func (c *Client) TokenScopes() []string {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	return slices.Clone(c.auth.scopes)
+}
+
+// parseScopes returns scopes reported as an array or a space separated string, ignoring other forms
+func parseScopes(raw json.RawMessage) []string {
+	var scopes []string
+	if err := json.Unmarshal(raw, &scopes); err == nil {
+		return scopes
+	}
+
+	var scope string
+	if err := json.Unmarshal(raw, &scope); err == nil {
+		return strings.Fields(scope)
+	}
+
+	return nil
+}
+
+// TokenExpiry returns the token expiry in local time, zero when unknown
+func (c *Client) TokenExpiry() time.Time {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	if c.auth.expiry.IsZero() {
+		return time.Time{}
+	}
+
+	return c.auth.expiry.Add(-c.auth.offset)
+}
+
+// WithBackgroundRefresh sets whether a goroutine refreshes the token ahead of its expiry,
+// so requests never wait for a refresh. It stops on Close.
+func WithBackgroundRefresh(backgroundRefresh bool) Option {
+	return func(c *Client) {
+		c.config.BackgroundRefresh = backgroundRefresh
+	}
+}
+
+// WithRefreshAhead sets how long before requests would refresh the token the background refresh does it
+func WithRefreshAhead(refreshAhead time.Duration) Option {
+	return func(c *Client) {
+		c.refreshAhead = refreshAhead
+	}
+}
+
+// WithRefreshJitter sets the range of a random extra time the background refresh is done ahead,
+// drawn for each token, so instances sharing a secret do not refresh all at once
+func WithRefreshJitter(refreshJitter time.Duration) Option {
+	return func(c *Client) {
+		c.refreshJitter = refreshJitter
+	}
+}
+
+// drawJitter returns a random extra time to refresh ahead within the refresh jitter, must be called
+// with the refresh mutex held
+func (c *Client) drawJitter() time.Duration {
+	if c.refreshJitter <= 0 {
+		return 0
+	}
+
+	return time.Duration(c.auth.rng.Int64N(int64(c.refreshJitter)))
+}
+
+// refreshLoop refreshes the token in background until the client is closed, which also cancels
+// an in-flight refresh
+func (c *Client) refreshLoop() {
+	// Derive refresh context cancelled on Close
+	ctx, cancel := context.WithCancel(c.baseCtx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	failures := 0
+	for {
+		// Wait until refresh is due, or back off after failures, still retrying within the refresh ahead
+		wait := c.untilRefresh()
+		if failures > 0 {
+			wait = min(c.backoffStrategy().NextDelay(failures-1), c.refreshRetryLimit())
+		}
+		if err := c.waitClosed(wait); err != nil {
+			return
+		}
+		if failures == 0 && c.untilRefresh() > 0 {
+			continue
+		}
+
+		// Refresh token, coordinating with requests via the refresh mutex
+		c.auth.mu.Lock()
+		err := applyToken(ctx, c)
+		c.auth.mu.Unlock()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			c.Logger.Warn(nil, fmt.Sprintf("background token refresh failed (%d in a row): %s", failures, err.Error()))
+			continue
+		}
+
+		failures = 0
+		c.Logger.Info(nil, "background token refresh succeeded")
+	}
+}
+
+// refreshRetryLimit returns the longest wait before retrying a failed background refresh
+func (c *Client) refreshRetryLimit() time.Duration {
+	if c.refreshAhead > 0 {
+		return c.refreshAhead
+	}
+
+	return time.Minute
+}
+
+// untilRefresh returns how long until the background refresh is due
+func (c *Client) untilRefresh() time.Duration {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	// Refresh missing token now
+	if c.auth.token == "" {
+		return 0
+	}
+
+	// Check again later when expiry is unknown
+	if c.auth.expiry.IsZero() {
+		return c.refreshAhead
+	}
+
+	due := c.auth.expiry.Add(-c.auth.offset - c.expirySkew - c.clockSkew - c.refreshAhead - c.auth.jitter)
+	return max(time.Until(due), 0)
+}
+
+// waitClosed waits for the duration, failing with ErrClientClosed if the client is closed meanwhile,
+// or with the error of the base context if it is done
+func (c *Client) waitClosed(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-c.closed:
+		return ErrClientClosed
+	case <-c.baseCtx.Done():
+		return c.baseCtx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tokenExpired reports whether the token should be refreshed, must be called with the refresh mutex held
+func (c *Client) tokenExpired() bool {
+	// Refresh missing token
+	if c.auth.token == "" {
+		return true
+	}
+
+	// Rely on upstream when expiry is unknown
+	if c.auth.expiry.IsZero() {
+		return false
+	}
+
+	// Estimate server time
+	now := time.Now().Add(c.auth.offset)
+
+	return !now.Add(c.expirySkew + c.clockSkew).Before(c.auth.expiry)
+}
+
+// ensureToken refreshes the token when it is missing or about to expire and returns it
+func (c *Client) ensureToken(ctx context.Context) (string, error) {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	if c.tokenExpired() {
+		if err := applyToken(ctx, c); err != nil {
+			return "", err
+		}
+	}
+
+	return c.auth.token, nil
+}
+
+// currentToken returns the token without refreshing it, failing with ErrTokenExpired when it should be refreshed
+func (c *Client) currentToken() (string, error) {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	if c.tokenExpired() {
+		return "", ErrTokenExpired
+	}
+
+	return c.auth.token, nil
+}
+
+// renewToken refreshes the token unless another request has already replaced the stale one and returns it
+func (c *Client) renewToken(ctx context.Context, stale string) (string, error) {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	if c.auth.token == stale {
+		if err := applyToken(ctx, c); err != nil {
+			return "", err
+		}
+	}
+
+	return c.auth.token, nil
+}
+
+// serverOffset estimates the difference between the server clock and the local clock
+func serverOffset(header http.Header) time.Duration {
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return 0
+	}
+
+	return time.Until(date)
+}
+
+// maskToken returns the token with all but a short prefix masked for logging
+func maskToken(token string) string {
+	if len(token) <= 8 {
+		return "***"
+	}
+
+	return token[:4] + "***"
+}
@@ -0,0 +1,313 @@
+package client
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newRefreshServer starts a server serving a token expiring in expiresIn on the first token
+// request and handling later token requests with refresh
+func newRefreshServer(t *testing.T, expiresIn time.Duration, refresh http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	var tokenRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tokenRequests.Add(1) == 1 {
+			writeEnvelope(w, CodeSuccess, "ok", fmt.Sprintf(
+				`{"token":%q,"expiry":%d}`, testToken, time.Now().Add(expiresIn).Unix(),
+			))
+			return
+		}
+		refresh(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestBackgroundRefreshRetriesWithinRefreshAhead(t *testing.T) {
+	attempts := make(chan struct{}, 10)
+	server := newRefreshServer(t, 0, func(w http.ResponseWriter, r *http.Request) {
+		attempts <- struct{}{}
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	newTestClient(t, server,
+		WithBackgroundRefresh(true),
+		WithRefreshAhead(100*time.Millisecond),
+		WithRefreshJitter(0),
+		WithExpirySkew(0),
+		WithClockSkew(0),
+		WithMaxRetries(1),
+		WithBackoff(ConstantBackoff{Delay: 24 * time.Hour}),
+	)
+
+	// A day of backoff is capped at the refresh ahead, so expect a retry soon after the first failure
+	for i := range 2 {
+		select {
+		case <-attempts:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("background refresh attempt %d not made", i+1)
+		}
+	}
+}
+
+func TestBackgroundRefreshRetryLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		refreshAhead time.Duration
+		want         time.Duration
+	}{
+		{"refresh ahead", 50 * time.Millisecond, 50 * time.Millisecond},
+		{"no refresh ahead", 0, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{refreshAhead: tt.refreshAhead}
+			if limit := c.refreshRetryLimit(); limit != tt.want {
+				t.Errorf("refreshRetryLimit() = %v, want %v", limit, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloseCancelsBackgroundRefresh(t *testing.T) {
+	started := make(chan struct{}, 1)
+	cancelled := make(chan struct{}, 1)
+	server := newRefreshServer(t, time.Hour, func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+		cancelled <- struct{}{}
+	})
+	c := newTestClient(t, server,
+		WithBackgroundRefresh(true),
+		WithRefreshAhead(2*time.Hour),
+		WithRefreshJitter(0),
+		WithMaxRetries(1),
+	)
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("background refresh not attempted")
+	}
+	_ = c.Close()
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight background refresh not cancelled by Close")
+	}
+}
+
+func TestRefreshJitterSpread(t *testing.T) {
+	const (
+		refreshAhead = time.Minute
+		jitter       = 30 * time.Second
+		tokens       = 200
+	)
+	c := &Client{
+		refreshAhead:  refreshAhead,
+		refreshJitter: jitter,
+		auth: &tokenState{
+			token: testToken,
+			rng:   rand.New(rand.NewPCG(1, 2)),
+		},
+	}
+
+	// Draw refresh times of tokens expiring an hour from now
+	expiry := time.Now().Add(time.Hour)
+	earliest, latest := time.Duration(math.MaxInt64), time.Duration(0)
+	for range tokens {
+		c.auth.expiry = expiry
+		c.auth.jitter = c.drawJitter()
+		ahead := time.Until(expiry) - c.untilRefresh()
+		if ahead < refreshAhead || ahead > refreshAhead+jitter+time.Second {
+			t.Fatalf("refreshed %v ahead of expiry, want within [%v, %v]", ahead, refreshAhead, refreshAhead+jitter)
+		}
+		earliest, latest = min(earliest, ahead), max(latest, ahead)
+	}
+
+	// Expect refresh times to cover most of the window
+	if spread := latest - earliest; spread < jitter*3/4 {
+		t.Errorf("refresh times spread over %v, want at least %v", spread, jitter*3/4)
+	}
+}
+
+func TestRefreshJitterDisabled(t *testing.T) {
+	c := &Client{auth: newTokenState()}
+	if jitter := c.drawJitter(); jitter != 0 {
+		t.Errorf("drawJitter() = %v, want 0", jitter)
+	}
+}
+
+// newRotatingServer starts a server issuing token-1, token-2 and so on, and handling other
+// requests with handler
+func newRotatingServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	var tokens atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == TokenEndpoint {
+			writeEnvelope(w, CodeSuccess, "ok", fmt.Sprintf(`{"token":"token-%d"}`, tokens.Add(1)))
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTokenExpiredRetryAuthorization(t *testing.T) {
+	var mu sync.Mutex
+	var authorizations [][]string
+	server := newRotatingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorizations = append(authorizations, r.Header.Values("Authorization"))
+		mu.Unlock()
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			writeEnvelope(w, CodeTokenExpired, "token expired", "null")
+			return
+		}
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	})
+	c := newTestClient(t, server)
+
+	if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken(); result.Err != nil || !result.OK() {
+		t.Fatalf("WithToken() = %d, error = %v, want success", result.Code, result.Err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"Bearer token-1", "Bearer token-2"}
+	if len(authorizations) != len(want) {
+		t.Fatalf("got %d attempts, want %d", len(authorizations), len(want))
+	}
+	for i, got := range authorizations {
+		if len(got) != 1 || got[0] != want[i] {
+			t.Errorf("attempt %d: Authorization = %q, want single %q", i+1, got, want[i])
+		}
+	}
+}
+
+func TestAutoRefresh(t *testing.T) {
+	tests := []struct {
+		name        string
+		autoRefresh bool
+		wantErr     error
+		wantCalls   int32
+	}{
+		{"enabled", true, nil, 2},
+		{"disabled", false, ErrTokenExpired, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := newRotatingServer(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if r.Header.Get("Authorization") == "Bearer token-1" {
+					writeEnvelope(w, CodeTokenExpired, "token expired", "null")
+					return
+				}
+				writeEnvelope(w, CodeSuccess, "ok", "null")
+			})
+			c := newTestClient(t, server, WithAutoRefresh(tt.autoRefresh))
+
+			result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken()
+			if !errors.Is(result.Err, tt.wantErr) {
+				t.Fatalf("WithToken() error = %v, want %v", result.Err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestTokenResponseEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		gzip    bool
+		options []Option
+	}{
+		{"chunked", false, nil},
+		{"gzip chunked", true, nil},
+		{"gzip chunked with accept encoding", true, []Option{
+			WithDefaultHeaders(http.Header{"Accept-Encoding": {"gzip"}}),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != TokenEndpoint {
+					if r.Header.Get("Authorization") != "Bearer "+testToken {
+						writeEnvelope(w, CodeTokenExpired, "bad token", "null")
+						return
+					}
+					writeEnvelope(w, CodeSuccess, "ok", "null")
+					return
+				}
+
+				// Write the token in two flushed chunks, compressed when asked
+				w.Header().Set("Content-Type", ContentTypeJSON)
+				var out io.Writer = w
+				if tt.gzip {
+					w.Header().Set("Content-Encoding", "gzip")
+					gz := gzip.NewWriter(w)
+					defer func() {
+						_ = gz.Close()
+					}()
+					out = gz
+				}
+				body := fmt.Sprintf(`{"code":%d,"msg":"ok","data":{"token":%q}}`, CodeSuccess, testToken)
+				_, _ = io.WriteString(out, body[:10])
+				if gz, ok := out.(*gzip.Writer); ok {
+					_ = gz.Flush()
+				}
+				w.(http.Flusher).Flush()
+				_, _ = io.WriteString(out, body[10:])
+			}))
+			t.Cleanup(server.Close)
+			c := newTestClient(t, server, append(tt.options, WithAutoRefresh(false))...)
+
+			if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken(); result.Err != nil || !result.OK() {
+				t.Errorf("WithToken() = %d, error = %v, want success with the served token", result.Code, result.Err)
+			}
+		})
+	}
+}
+
+// countingBackoff counts the delays asked for
+type countingBackoff struct {
+	delays atomic.Int32
+}
+
+// NextDelay counts the delay and returns none
+func (b *countingBackoff) NextDelay(int) time.Duration {
+	b.delays.Add(1)
+	return 0
+}
+
+func TestTokenExpiredWaitsOncePerRetry(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, CodeTokenExpired, "token expired", "null")
+	})
+	backoff := &countingBackoff{}
+	c := newTestClient(t, server, WithMaxRetries(3), WithBackoff(backoff))
+
+	if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithKey(); result.Code != CodeTokenExpired {
+		t.Fatalf("WithKey() code = %d, error = %v, want %d", result.Code, result.Err, CodeTokenExpired)
+	}
+	if got := backoff.delays.Load(); got != 2 {
+		t.Errorf("waited %d times, want 2 between 3 attempts", got)
+	}
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithClientTrace sets whether DNS, connect, TLS and first byte timings of requests are traced,
+// logged and reported in Result.Timings
+func WithClientTrace(clientTrace bool) Option {
+	return func(c *Client) {
+		c.clientTrace = clientTrace
+	}
+}
+
+// Timings provides phase durations of a request, zero for phases that did not happen,
+// e.g. DNS, Connect and TLSHandshake on a reused connection
+type Timings struct {
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	FirstByte    time.Duration
+	Total        time.Duration
+	Reused       bool
+}
+
+// requestTrace collects phase timings of a request, callbacks may run on different goroutines
+type requestTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+// newRequestTrace starts tracing a request
+func newRequestTrace() *requestTrace {
+	return &requestTrace{
+		start: time.Now(),
+	}
+}
+
+// clientTrace returns hooks recording the phases
+func (t *requestTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.record(func() { t.reused = info.Reused })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(func() { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func() { t.dnsDone = time.Now() })
+		},
+		ConnectStart: func(string, string) {
+			t.record(func() {
+				if t.connectStart.IsZero() {
+					t.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(string, string, error) {
+			t.record(func() { t.connectDone = time.Now() })
+		},
+		TLSHandshakeStart: func() {
+			t.record(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func() { t.tlsDone = time.Now() })
+		},
+		GotFirstResponseByte: func() {
+			t.record(func() { t.firstByte = time.Now() })
+		},
+	}
+}
+
+// record updates the trace under its lock
+func (t *requestTrace) record(update func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	update()
+}
+
+// String returns the phase timings for logging
+func (t *requestTrace) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	phases := []string{fmt.Sprintf("reused connection %t", t.reused)}
+	for _, phase := range []struct {
+		name       string
+		start, end time.Time
+	}{
+		{"dns", t.dnsStart, t.dnsDone},
+		{"connect", t.connectStart, t.connectDone},
+		{"tls", t.tlsStart, t.tlsDone},
+		{"first byte", t.start, t.firstByte},
+	} {
+		if !phase.start.IsZero() && !phase.end.IsZero() {
+			phases = append(phases, fmt.Sprintf("%s %s", phase.name, phase.end.Sub(phase.start)))
+		}
+	}
+	phases = append(phases, fmt.Sprintf("total %s", time.Since(t.start)))
+
+	return strings.Join(phases, ", ")
+}
+
+// timings returns the phase durations so far
+func (t *requestTrace) timings() *Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	phase := func(start, end time.Time) time.Duration {
+		if start.IsZero() || end.IsZero() {
+			return 0
+		}
+		return end.Sub(start)
+	}
+
+	return &Timings{
+		DNS:          phase(t.dnsStart, t.dnsDone),
+		Connect:      phase(t.connectStart, t.connectDone),
+		TLSHandshake: phase(t.tlsStart, t.tlsDone),
+		FirstByte:    phase(t.start, t.firstByte),
+		Total:        time.Since(t.start),
+		Reused:       t.reused,
+	}
+}
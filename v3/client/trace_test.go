@@ -0,0 +1,40 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientTraceTimings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, CodeSuccess, "ok", "null")
+	}))
+	t.Cleanup(server.Close)
+
+	c := newTestClient(t, server, WithLazyToken(true), WithHTTPClient(server.Client()), WithClientTrace(true))
+	first := c.Send(c.URL("/data"), http.MethodGet, nil).WithoutAuth()
+	if first.Err != nil || first.Timings == nil {
+		t.Fatalf("Send() timings = %v, error = %v, want timings", first.Timings, first.Err)
+	}
+	timings := first.Timings
+	if timings.Connect <= 0 || timings.TLSHandshake <= 0 || timings.FirstByte <= 0 || timings.Total <= 0 || timings.Reused {
+		t.Errorf("Timings = %+v, want non-zero connect, TLS, first byte and total on a new connection", *timings)
+	}
+
+	// A reused connection skips connect and TLS
+	second := c.Send(c.URL("/data"), http.MethodGet, nil).WithoutAuth()
+	if second.Err != nil || second.Timings == nil {
+		t.Fatalf("Send() timings = %v, error = %v, want timings", second.Timings, second.Err)
+	}
+	timings = second.Timings
+	if !timings.Reused || timings.Connect != 0 || timings.TLSHandshake != 0 || timings.FirstByte <= 0 {
+		t.Errorf("Timings = %+v, want only first byte and total on a reused connection", *timings)
+	}
+
+	// Timings are not collected without trace
+	c = newTestClient(t, server, WithLazyToken(true), WithHTTPClient(server.Client()))
+	if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithoutAuth(); result.Timings != nil {
+		t.Errorf("Timings = %+v, want nil without trace", *result.Timings)
+	}
+}
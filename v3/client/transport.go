@@ -0,0 +1,90 @@
+package client
+
+import (
+	"net/http"
+)
+
+// RetryTransport retries requests failing at transport level, such as a connection reset or
+// DNS failure, before any response is received. These retries are always safe to repeat and
+// happen beneath the application retries of WithMaxRetries, which act on HTTP status and API code.
+type RetryTransport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	Backoff    BackoffStrategy
+}
+
+// RoundTrip sends the request, retrying transport failures
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Load default base
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	for attempt := 0; ; attempt++ {
+		// Rewind body of retries
+		try := req
+		if attempt > 0 {
+			try = req.Clone(req.Context())
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				try.Body = body
+			}
+		}
+
+		// Send request
+		res, err := base.RoundTrip(try)
+		if err == nil || attempt >= t.MaxRetries || !t.retryable(req) || !retriesAllowed(req.Context()) {
+			return res, err
+		}
+
+		// Wait before retrying
+		if t.Backoff != nil {
+			if err = sleep(req.Context(), t.Backoff.NextDelay(attempt)); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// CloseIdleConnections closes idle connections of the base transport
+func (t *RetryTransport) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if transport, ok := base.(closeIdler); ok {
+		transport.CloseIdleConnections()
+	}
+}
+
+// retryable reports whether the request can be sent again
+func (t *RetryTransport) retryable(req *http.Request) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// WithTransportRetries sets max retries of transport failures, independent of WithMaxRetries
+func WithTransportRetries(transportRetries int) Option {
+	return func(c *Client) {
+		c.config.TransportRetries = transportRetries
+	}
+}
+
+// WithHTTPClient sets http client used to send requests, WithTimeout and WithForceHTTP2
+// are not applied to it while WithTransportRetries wraps a copy of its transport
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRetryTransportNoRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      []CallOption
+		wantAttempts int32
+	}{
+		{"retried", nil, 3},
+		{"no retry", []CallOption{WithNoRetry()}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			connErr := errors.New("connection reset")
+			transport := &RetryTransport{
+				Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					attempts.Add(1)
+					return nil, connErr
+				}),
+				MaxRetries: 2,
+			}
+
+			opts := newCallOptions(t.Context(), tt.options)
+			req, err := http.NewRequestWithContext(opts.ctx, http.MethodGet, "http://upstream.test", nil)
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+			if _, err = transport.RoundTrip(req); !errors.Is(err, connErr) {
+				t.Fatalf("RoundTrip() error = %v, want %v", err, connErr)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("got %d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryTransportNoBody(t *testing.T) {
+	var attempts atomic.Int32
+	connErr := errors.New("connection reset")
+	transport := &RetryTransport{
+		Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts.Add(1)
+			return nil, connErr
+		}),
+		MaxRetries: 2,
+	}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "http://upstream.test", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.GetBody = nil
+	if _, err = transport.RoundTrip(req); !errors.Is(err, connErr) {
+		t.Fatalf("RoundTrip() error = %v, want %v", err, connErr)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("got %d attempts, want 3", got)
+	}
+}
+
+func TestTransportAndClientRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		options          []Option
+		payload          func() any
+		fail             bool
+		wantAttempts     int32
+		wantTransportErr bool
+	}{
+		{"transport retries only", []Option{WithTransportRetries(2), WithMaxRetries(1)},
+			func() any { return nil }, true, 3, true},
+		{"client retries only", []Option{WithTransportRetries(0), WithMaxRetries(3)},
+			func() any { return nil }, true, 3, true},
+		{"statuses left to client retries", []Option{WithTransportRetries(2), WithMaxRetries(3)},
+			func() any { return nil }, false, 3, false},
+		{"seekable body", []Option{WithTransportRetries(2), WithMaxRetries(1)},
+			func() any { return strings.NewReader("body") }, true, 3, true},
+		{"unseekable body", []Option{WithTransportRetries(2), WithMaxRetries(3)},
+			func() any { return io.MultiReader(strings.NewReader("body")) }, true, 1, true},
+		{"no body", []Option{WithTransportRetries(2), WithMaxRetries(1)},
+			func() any { return http.NoBody }, true, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			connErr := errors.New("connection reset")
+			transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				attempts.Add(1)
+				if req.Body != nil {
+					_, _ = io.Copy(io.Discard, req.Body)
+				}
+				if tt.fail {
+					return nil, connErr
+				}
+				return &http.Response{
+					StatusCode: http.StatusInternalServerError,
+					Header:     http.Header{},
+					Body:       http.NoBody,
+					Request:    req,
+				}, nil
+			})
+			options := append([]Option{
+				WithEndpoint("http://upstream.test"),
+				WithHTTPClient(&http.Client{Transport: transport}),
+				WithLogger(NewLogger(WithLoggerOutput(io.Discard))),
+				WithLazyToken(true),
+				WithRetryDelay(0),
+				WithBackoff(ConstantBackoff{}),
+			}, tt.options...)
+			c, err := NewClient("id", "key", options...)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			t.Cleanup(func() {
+				_ = c.Close()
+			})
+
+			result := c.Send(c.URL("/data"), http.MethodPost, tt.payload()).WithoutAuth()
+			if result.Err == nil {
+				t.Fatal("Send() error = nil, want error")
+			}
+			if transportErr := errors.Is(result.Err, connErr); transportErr != tt.wantTransportErr {
+				t.Errorf("error = %v, want transport error %v", result.Err, tt.wantTransportErr)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("got %d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -7,11 +7,26 @@ import (
 
 const Endpoint = "https://api.gh.ink/v3"
 
+// ShortDomain is the default domain of public short links
+const ShortDomain = "gh.ink"
+
 var Version = [3]int{3, 0, 0}
-var UserAgent = fmt.Sprintf(
-	"GhinkOpenAPISDK-Go/%d.%d.%d (%s; %s)",
-	Version[0], Version[1], Version[2],
-	runtime.GOOS, runtime.GOARCH,
-)
+
+// UserAgent is the default User-Agent sent by the SDK
+var UserAgent = BuildUserAgent("")
+
+// BuildUserAgent returns the User-Agent of the SDK, led by appName when not empty
+func BuildUserAgent(appName string) string {
+	userAgent := fmt.Sprintf(
+		"GhinkOpenAPISDK-Go/%d.%d.%d (%s; %s)",
+		Version[0], Version[1], Version[2],
+		runtime.GOOS, runtime.GOARCH,
+	)
+	if appName != "" {
+		userAgent = fmt.Sprintf("%s %s", appName, userAgent)
+	}
+
+	return userAgent
+}
 
 type MapAny map[string]any
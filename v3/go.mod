@@ -1,3 +1,5 @@
 module go.gh.ink/openapi/sdk/20260422/v3
 
 go 1.25.0
+
+require golang.org/x/text v0.36.0
@@ -0,0 +1,15 @@
+module go.gh.ink/openapi/sdk/20260422/v3/logger/logrusadapter
+
+go 1.25.0
+
+require (
+	github.com/sirupsen/logrus v1.10.2
+	go.gh.ink/openapi/sdk/20260422/v3 v3.0.0
+)
+
+require (
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+)
+
+replace go.gh.ink/openapi/sdk/20260422/v3 => ../..
@@ -0,0 +1,57 @@
+package logrusadapter
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+// Logger adapts a logrus logger to the SDK logger, promoting context log fields to logrus fields
+type Logger struct {
+	logger logrus.FieldLogger
+}
+
+// New creates an SDK logger writing to logger
+func New(logger logrus.FieldLogger) client.Logger {
+	return Logger{
+		logger: logger,
+	}
+}
+
+// entry returns the entry carrying the context and its log fields
+func (l Logger) entry(ctx context.Context) *logrus.Entry {
+	entry := l.logger.WithFields(nil)
+	if ctx == nil {
+		return entry
+	}
+
+	logFields := client.LogFields(ctx)
+	fields := make(logrus.Fields, len(logFields))
+	for key, value := range logFields {
+		fields[key] = value
+	}
+
+	return entry.WithContext(ctx).WithFields(fields)
+}
+
+// Debug build Debug level log
+func (l Logger) Debug(ctx context.Context, args ...any) {
+	l.entry(ctx).Debug(args...)
+}
+
+// Info build Info level log
+func (l Logger) Info(ctx context.Context, args ...any) {
+	l.entry(ctx).Info(args...)
+}
+
+// Warn build Warn level log
+func (l Logger) Warn(ctx context.Context, args ...any) {
+	l.entry(ctx).Warn(args...)
+}
+
+// Error build Error level log
+func (l Logger) Error(ctx context.Context, args ...any) {
+	l.entry(ctx).Error(args...)
+}
@@ -0,0 +1,15 @@
+module go.gh.ink/openapi/sdk/20260422/v3/logger/zapadapter
+
+go 1.25.0
+
+require (
+	go.gh.ink/openapi/sdk/20260422/v3 v3.0.0
+	go.uber.org/zap v1.28.0
+)
+
+require (
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+)
+
+replace go.gh.ink/openapi/sdk/20260422/v3 => ../..
@@ -0,0 +1,53 @@
+package zapadapter
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+// Logger adapts a zap logger to the SDK logger, promoting context log fields to zap fields
+type Logger struct {
+	logger *zap.Logger
+}
+
+// New creates an SDK logger writing to logger
+func New(logger *zap.Logger) client.Logger {
+	return Logger{
+		logger: logger.WithOptions(zap.AddCallerSkip(1)),
+	}
+}
+
+// fields returns the context log fields as zap fields
+func fields(ctx context.Context) []zap.Field {
+	logFields := client.LogFields(ctx)
+	zapFields := make([]zap.Field, 0, len(logFields))
+	for key, value := range logFields {
+		zapFields = append(zapFields, zap.String(key, value))
+	}
+
+	return zapFields
+}
+
+// Debug build Debug level log
+func (l Logger) Debug(ctx context.Context, args ...any) {
+	l.logger.Debug(fmt.Sprint(args...), fields(ctx)...)
+}
+
+// Info build Info level log
+func (l Logger) Info(ctx context.Context, args ...any) {
+	l.logger.Info(fmt.Sprint(args...), fields(ctx)...)
+}
+
+// Warn build Warn level log
+func (l Logger) Warn(ctx context.Context, args ...any) {
+	l.logger.Warn(fmt.Sprint(args...), fields(ctx)...)
+}
+
+// Error build Error level log
+func (l Logger) Error(ctx context.Context, args ...any) {
+	l.logger.Error(fmt.Sprint(args...), fields(ctx)...)
+}
@@ -0,0 +1,107 @@
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+// Namespace is the namespace of the metrics
+const Namespace = "ghink_openapi"
+
+// Observer exports calls and token refreshes of clients as Prometheus metrics, it is both a
+// client.Observer and a prometheus.Collector
+type Observer struct {
+	calls          *prometheus.CounterVec
+	duration       *prometheus.HistogramVec
+	inFlight       *prometheus.GaugeVec
+	retries        *prometheus.CounterVec
+	tokenRefreshes *prometheus.CounterVec
+}
+
+// New creates an observer, register it before use
+func New() *Observer {
+	return &Observer{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "requests_total",
+			Help:      "Calls done by method, HTTP status and API code, both 0 when no response is received.",
+		}, []string{"method", "status", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of calls including retries by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "requests_in_flight",
+			Help:      "Calls in flight by method.",
+		}, []string{"method"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "retries_total",
+			Help:      "Attempts retried by method.",
+		}, []string{"method"}),
+		tokenRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "token_refreshes_total",
+			Help:      "Token refreshes by result, success or failure.",
+		}, []string{"result"}),
+	}
+}
+
+// Register creates an observer, registers it to registerer and returns the option wiring it to a client
+func Register(registerer prometheus.Registerer) (client.Option, error) {
+	observer := New()
+	if err := registerer.Register(observer); err != nil {
+		return nil, err
+	}
+
+	return client.WithObserver(observer), nil
+}
+
+// collectors returns the collectors of the metrics
+func (o *Observer) collectors() []prometheus.Collector {
+	return []prometheus.Collector{o.calls, o.duration, o.inFlight, o.retries, o.tokenRefreshes}
+}
+
+// Describe sends descriptors of the metrics
+func (o *Observer) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range o.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect sends the metrics
+func (o *Observer) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range o.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// CallStarted counts the call in flight
+func (o *Observer) CallStarted(method string) {
+	o.inFlight.WithLabelValues(method).Inc()
+}
+
+// CallFinished records the outcome of the call
+func (o *Observer) CallFinished(event client.CallEvent) {
+	o.inFlight.WithLabelValues(event.Method).Dec()
+	o.calls.WithLabelValues(event.Method, strconv.Itoa(event.StatusCode), strconv.Itoa(event.Code)).Inc()
+	o.duration.WithLabelValues(event.Method).Observe(event.Duration.Seconds())
+	if event.Attempts > 1 {
+		o.retries.WithLabelValues(event.Method).Add(float64(event.Attempts - 1))
+	}
+}
+
+// TokenRefreshed counts the token refresh by result
+func (o *Observer) TokenRefreshed(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	o.tokenRefreshes.WithLabelValues(result).Inc()
+}
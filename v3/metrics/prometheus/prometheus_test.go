@@ -0,0 +1,67 @@
+package prometheus
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+func TestRegister(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", client.ContentTypeJSON)
+		if r.URL.Path == client.TokenEndpoint {
+			_, _ = fmt.Fprintf(w, `{"code":%d,"msg":"ok","data":{"token":"test-token"}}`, client.CodeSuccess)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"code":%d,"msg":"ok","data":null}`, client.CodeSuccess)
+	}))
+	t.Cleanup(server.Close)
+
+	registry := prometheus.NewRegistry()
+	option, err := Register(registry)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if _, err = Register(registry); err == nil {
+		t.Error("Register() twice error = nil, want already registered")
+	}
+
+	c, err := client.NewClient("id", "key",
+		client.WithEndpoint(server.URL),
+		client.WithLogger(client.NewLogger(client.WithLoggerOutput(io.Discard))),
+		option,
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	if result := c.Send(c.URL("/data"), http.MethodGet, nil).WithToken(); result.Err != nil {
+		t.Fatalf("Send() error = %v", result.Err)
+	}
+
+	// Compare counters gathered from the registry, the token GET is counted as a call too
+	expected := fmt.Sprintf(`
+# HELP ghink_openapi_requests_total Calls done by method, HTTP status and API code, both 0 when no response is received.
+# TYPE ghink_openapi_requests_total counter
+ghink_openapi_requests_total{code="%[1]d",method="GET",status="200"} 2
+# HELP ghink_openapi_token_refreshes_total Token refreshes by result, success or failure.
+# TYPE ghink_openapi_token_refreshes_total counter
+ghink_openapi_token_refreshes_total{result="success"} 1
+`, client.CodeSuccess)
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		Namespace+"_requests_total", Namespace+"_token_refreshes_total",
+	)
+	if err != nil {
+		t.Error(err)
+	}
+}
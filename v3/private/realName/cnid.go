@@ -84,8 +84,35 @@ func IsValidDate(year, month, day int) bool {
 	return err == nil
 }
 
+// Fields reported by CNIDResult.Mismatches
+const (
+	MismatchName = "name"
+	MismatchID   = "id"
+)
+
+// CNIDResult provides a detailed result of CNID verification,
+// NameMatch and IDMatch are nil when upstream does not report them
+type CNIDResult struct {
+	Ok        bool  `json:"ok"`
+	NameMatch *bool `json:"nameMatch"`
+	IDMatch   *bool `json:"idMatch"`
+}
+
+// Mismatches returns the fields reported as mismatched, empty when verified or not reported
+func (r *CNIDResult) Mismatches() []string {
+	var mismatches []string
+	if r.NameMatch != nil && !*r.NameMatch {
+		mismatches = append(mismatches, MismatchName)
+	}
+	if r.IDMatch != nil && !*r.IDMatch {
+		mismatches = append(mismatches, MismatchID)
+	}
+
+	return mismatches
+}
+
 // VerifyCNID verifies whether the provided CNID is valid
-func VerifyCNID(c *client.Client, id string, name string) (ok bool, err error) {
+func VerifyCNID(c *client.Client, id string, name string, options ...client.CallOption) (ok bool, err error) {
 	// Pre-process ID
 	id = strings.ToLower(id)
 
@@ -94,6 +121,64 @@ func VerifyCNID(c *client.Client, id string, name string) (ok bool, err error) {
 		return false, nil
 	}
 
+	// Send request
+	result, err := sendCNID(c, id, name, options...)
+	if err != nil {
+		return false, err
+	}
+
+	// Check status code and read ok
+	if ok, err = result.DecodeOK(); err != nil {
+		c.Logger.Error(nil, fmt.Sprintf("failed to verify CNID, %s", err.Error()))
+		return false, fmt.Errorf("failed to verify CNID, %w", err)
+	}
+
+	return ok, nil
+}
+
+// VerifyCNIDDetail verifies whether the provided CNID is valid and reports mismatched fields.
+// Unlike VerifyCNID it decodes the whole data after RequireOK, as it needs the match fields.
+func VerifyCNIDDetail(c *client.Client, id string, name string, options ...client.CallOption) (*CNIDResult, error) {
+	// Pre-process ID
+	id = strings.ToLower(id)
+
+	// Check CNID format valid
+	if !IsValidID(id) {
+		idMatch := false
+		return &CNIDResult{
+			Ok:      false,
+			IDMatch: &idMatch,
+		}, nil
+	}
+
+	// Send request
+	result, err := sendCNID(c, id, name, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check status code
+	if err := result.RequireOK(); err != nil {
+		c.Logger.Error(nil, fmt.Sprintf("failed to verify CNID, %s", err.Error()))
+		return nil, fmt.Errorf("failed to verify CNID, %w", err)
+	}
+
+	// Build verify result struct
+	var Ok CNIDResult
+
+	// Unmarshal token data
+	if err := result.DecodeLenient(&Ok); err != nil {
+		c.Logger.Error(nil, fmt.Sprintf(
+			"failed to verify CNID, unmarshal error: %s", err.Error(),
+		))
+		return nil, err
+	}
+
+	return &Ok, nil
+}
+
+// sendCNID sends the verification request of a valid CNID
+func sendCNID(c *client.Client, id string, name string, options ...client.CallOption) (*client.Result, error) {
 	// Build payload
 	payload := openapi.MapAny{
 		"id":   id,
@@ -102,37 +187,27 @@ func VerifyCNID(c *client.Client, id string, name string) (ok bool, err error) {
 
 	// Send request
 	result := c.Send(
-		strings.Join([]string{c.GetEndpoint(), Endpoint, "/cnid"}, ""),
+		c.URL(Endpoint, "/cnid"),
 		http.MethodPost,
 		payload,
+		options...,
 	).WithToken()
 	if result.Err != nil {
 		c.Logger.Error(nil, fmt.Sprintf(
 			"failed to verify CNID, sender error: %s", result.Err.Error(),
 		))
-		return false, result.Err
+		return nil, result.Err
 	}
 
-	// Check status code
-	if !result.OK() {
-		c.Logger.Error(nil, fmt.Sprintf(
-			"failed to verify CNID, upstream failed: code: %d, msg: %s", result.Code, result.Msg,
-		))
-		return false, fmt.Errorf("failed to verify CNID, upstream failed: code: %d, msg: %s", result.Code, result.Msg)
-	}
-
-	// Build verify result struct
-	var Ok struct {
-		Ok bool `json:"ok"`
-	}
+	return result, nil
+}
 
-	// Unmarshal token data
-	if err = result.Unmarshal(&Ok); err != nil {
-		c.Logger.Error(nil, fmt.Sprintf(
-			"failed to verify CNID, unmarshal error: %s", result.Err.Error(),
-		))
+// VerifyCNIDDefault verifies whether the provided CNID is valid with the default client
+func VerifyCNIDDefault(id string, name string, options ...client.CallOption) (ok bool, err error) {
+	c, err := client.Default()
+	if err != nil {
 		return false, err
 	}
 
-	return Ok.Ok, nil
+	return VerifyCNID(c, id, name, options...)
 }
@@ -0,0 +1,110 @@
+package realName
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+// validID is a valid Chinese Mainland ID for tests
+const validID = "11010519491231002X"
+
+func TestVerifyCNID(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    bool
+		wantErr bool
+	}{
+		{"verified", `{"ok":true}`, true, false},
+		{"not verified", `{"ok":false,"nameMatch":false,"idMatch":true}`, false, false},
+		{"ok missing", `{}`, false, false},
+		{"malformed", `{"ok":"yes"}`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeEnvelope(w, client.CodeSuccess, "ok", tt.data)
+			})
+
+			ok, err := VerifyCNID(c, validID, "name")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyCNID() error = %v, want error %v", err, tt.wantErr)
+			}
+			if ok != tt.want {
+				t.Errorf("VerifyCNID() = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyCNIDUpstreamFailed(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, client.CodeForbidden, "forbidden", "null")
+	})
+
+	if _, err := VerifyCNID(c, validID, "name"); err == nil {
+		t.Error("VerifyCNID() error = nil, want error")
+	}
+	if _, err := VerifyCNIDDetail(c, validID, "name"); err == nil {
+		t.Error("VerifyCNIDDetail() error = nil, want error")
+	}
+}
+
+func TestVerifyCNIDInvalidID(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeEnvelope(w, client.CodeSuccess, "ok", `{"ok":true}`)
+	})
+
+	if ok, err := VerifyCNID(c, "110105194912310021", "name"); ok || err != nil {
+		t.Errorf("VerifyCNID() = %v, %v, want false, nil", ok, err)
+	}
+	detail, err := VerifyCNIDDetail(c, "110105194912310021", "name")
+	if err != nil {
+		t.Fatalf("VerifyCNIDDetail() error = %v", err)
+	}
+	if mismatches := detail.Mismatches(); len(mismatches) != 1 || mismatches[0] != MismatchID {
+		t.Errorf("Mismatches() = %v, want [%s]", mismatches, MismatchID)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("upstream called %d times, want 0", n)
+	}
+}
+
+func TestVerifyCNIDDetail(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, client.CodeSuccess, "ok", `{"ok":false,"nameMatch":false,"idMatch":true}`)
+	})
+
+	detail, err := VerifyCNIDDetail(c, validID, "name")
+	if err != nil {
+		t.Fatalf("VerifyCNIDDetail() error = %v", err)
+	}
+	if detail.Ok {
+		t.Error("Ok = true, want false")
+	}
+	if mismatches := detail.Mismatches(); len(mismatches) != 1 || mismatches[0] != MismatchName {
+		t.Errorf("Mismatches() = %v, want [%s]", mismatches, MismatchName)
+	}
+}
+
+func TestVerifyCNIDStrictDecoding(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, client.CodeSuccess, "ok", `{"ok":true,"nameMatch":true,"idMatch":true,"score":0.98}`)
+	}, client.WithStrictDecoding(true))
+
+	if ok, err := VerifyCNID(c, validID, "name"); !ok || err != nil {
+		t.Errorf("VerifyCNID() = %v, %v, want true, nil", ok, err)
+	}
+	detail, err := VerifyCNIDDetail(c, validID, "name")
+	if err != nil {
+		t.Fatalf("VerifyCNIDDetail() error = %v", err)
+	}
+	if !detail.Ok {
+		t.Error("VerifyCNIDDetail() Ok = false, want true")
+	}
+}
@@ -1,3 +1,9 @@
 package realName
 
+import "go.gh.ink/openapi/sdk/20260422/v3/client"
+
 const Endpoint = "/realName"
+
+func init() {
+	client.RegisterEndpoint("realName", Endpoint)
+}
@@ -0,0 +1,151 @@
+package realName
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+// writeEnvelope writes a response envelope of code, msg and data
+func writeEnvelope(w http.ResponseWriter, code int, msg string, data string) {
+	w.Header().Set("Content-Type", client.ContentTypeJSON)
+	_, _ = fmt.Fprintf(w, `{"code":%d,"msg":%q,"data":%s}`, code, msg, data)
+}
+
+// newTestClient starts a server answering token requests with a token and other requests with
+// handler, and creates a client of it
+func newTestClient(t *testing.T, handler http.HandlerFunc, options ...client.Option) *client.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == client.TokenEndpoint {
+			writeEnvelope(w, client.CodeSuccess, "ok", `{"token":"test-token-0123456789"}`)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	options = append([]client.Option{
+		client.WithEndpoint(server.URL),
+		client.WithLogger(client.NewLogger(client.WithLoggerOutput(io.Discard))),
+		client.WithRetryDelay(0),
+	}, options...)
+	c, err := client.NewClient("id", "key", options...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	return c
+}
+
+// newStreamClient creates a client of a CNID server verifying names "match", failing names
+// "fail" and holding names "hold" until the test ends
+func newStreamClient(t *testing.T) *client.Client {
+	t.Helper()
+
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Name string `json:"name"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		switch payload.Name {
+		case "fail":
+			writeEnvelope(w, client.CodeForbidden, "forbidden", "null")
+		case "hold":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		default:
+			writeEnvelope(w, client.CodeSuccess, "ok", fmt.Sprintf(`{"ok":%t}`, payload.Name == "match"))
+		}
+	})
+	t.Cleanup(func() {
+		close(release)
+	})
+
+	return c
+}
+
+// streamRequests returns a closed channel of requests
+func streamRequests(requests ...CNIDRequest) <-chan CNIDRequest {
+	ch := make(chan CNIDRequest, len(requests))
+	for _, request := range requests {
+		ch <- request
+	}
+	close(ch)
+
+	return ch
+}
+
+func TestVerifyCNIDStream(t *testing.T) {
+	c := newStreamClient(t)
+	requests := streamRequests(
+		CNIDRequest{ID: validID, Name: "match"},
+		CNIDRequest{ID: validID, Name: "fail"},
+		CNIDRequest{ID: validID, Name: "mismatch"},
+		CNIDRequest{ID: "110105194912310021", Name: "invalid"},
+	)
+
+	got := map[string]CNIDStreamResult{}
+	for result := range VerifyCNIDStream(t.Context(), c, requests, 2) {
+		got[result.Request.Name] = result
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d results, want 4", len(got))
+	}
+	if result := got["match"]; result.Err != nil || !result.Result.Ok {
+		t.Errorf("match = %+v, want ok", result)
+	}
+	if result := got["fail"]; result.Err == nil || result.Result != nil {
+		t.Errorf("fail = %+v, want error without result", result)
+	}
+	if result := got["mismatch"]; result.Err != nil || result.Result.Ok {
+		t.Errorf("mismatch = %+v, want not ok", result)
+	}
+	if result := got["invalid"]; result.Err != nil || result.Result.Ok {
+		t.Errorf("invalid = %+v, want not ok", result)
+	}
+}
+
+func TestVerifyCNIDStreamCancel(t *testing.T) {
+	c := newStreamClient(t)
+	requests := make(chan CNIDRequest, 2)
+	requests <- CNIDRequest{ID: validID, Name: "match"}
+	requests <- CNIDRequest{ID: validID, Name: "hold"}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	results := VerifyCNIDStream(ctx, c, requests, 1)
+	if result := <-results; result.Request.Name != "match" || result.Err != nil {
+		t.Fatalf("first result = %+v, want match", result)
+	}
+
+	// Cancel while a call is held and requests stay open
+	cancel()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				return
+			}
+			if result.Request.Name != "hold" {
+				t.Errorf("result = %+v, want only the held call", result)
+			}
+		case <-timeout:
+			t.Fatal("results not closed after cancel")
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package realName
+
+import (
+	"context"
+	"sync"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+// CNIDRequest provides a CNID and name to verify
+type CNIDRequest struct {
+	ID   string
+	Name string
+}
+
+// CNIDStreamResult provides the verification result of a request, Result is nil when Err is set
+type CNIDStreamResult struct {
+	Request CNIDRequest
+	Result  *CNIDResult
+	Err     error
+}
+
+// VerifyCNIDStream verifies requests with at most concurrency calls in flight and sends results
+// to the returned channel in completion order, not request order. The output is unbuffered, so
+// a slow consumer holds back the requests read. The output is closed once requests is closed and
+// drained, or once ctx is done, in which case unsent results are dropped.
+func VerifyCNIDStream(
+	ctx context.Context, c *client.Client, requests <-chan CNIDRequest, concurrency int, options ...client.CallOption,
+) <-chan CNIDStreamResult {
+	results := make(chan CNIDStreamResult)
+	options = append(options[:len(options):len(options)], client.WithContext(ctx))
+
+	// Run at least one worker
+	concurrency = max(concurrency, 1)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Go(func() {
+			for {
+				// Read next request
+				var request CNIDRequest
+				select {
+				case <-ctx.Done():
+					return
+				case next, ok := <-requests:
+					if !ok {
+						return
+					}
+					request = next
+				}
+
+				// Verify and send result
+				result, err := VerifyCNIDDetail(c, request.ID, request.Name, options...)
+				select {
+				case <-ctx.Done():
+					return
+				case results <- CNIDStreamResult{Request: request, Result: result, Err: err}:
+				}
+			}
+		})
+	}
+
+	// Close results once all workers are done
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
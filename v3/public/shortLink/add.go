@@ -3,7 +3,6 @@ package shortLink
 import (
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"go.gh.ink/openapi/sdk/20260422/v3"
@@ -11,7 +10,45 @@ import (
 )
 
 // Add a short link
-func Add(c *client.Client, link string, validity *time.Time) (ok string, err error) {
+func Add(c *client.Client, link string, validity *time.Time, options ...client.CallOption) (ok string, err error) {
+	linkID, _, err := AddWithValidity(c, link, validity, options...)
+	return linkID, err
+}
+
+// AddWithValidity adds a short link and returns the validity stored by upstream, which may be
+// clamped to the max validity allowed, falling back to the requested one if not echoed
+func AddWithValidity(
+	c *client.Client, link string, validity *time.Time, options ...client.CallOption,
+) (linkID string, effective time.Time, err error) {
+	linkID, effective, _, err = add(c, link, validity, false, options...)
+	return linkID, effective, err
+}
+
+// AddURL adds a short link and returns its public URL on the short domain of the client,
+// see client.WithShortDomain
+func AddURL(c *client.Client, link string, validity *time.Time, options ...client.CallOption) (shortURL string, err error) {
+	linkID, err := Add(c, link, validity, options...)
+	if err != nil {
+		return "", err
+	}
+
+	return c.ShortURL(linkID), nil
+}
+
+// AddOrGet adds a short link, or returns the existing one of the same link reported by upstream
+// instead of a duplicate, created tells which happened. A reply without the link ID fails with
+// ErrMissingLinkID rather than returning an empty one.
+func AddOrGet(
+	c *client.Client, link string, validity *time.Time, options ...client.CallOption,
+) (linkID string, created bool, err error) {
+	linkID, _, created, err = add(c, link, validity, true, options...)
+	return linkID, created, err
+}
+
+// add adds a short link, accepting the existing one of the same link if reuse
+func add(
+	c *client.Client, link string, validity *time.Time, reuse bool, options ...client.CallOption,
+) (linkID string, effective time.Time, created bool, err error) {
 	// Build payload
 	payload := openapi.MapAny{
 		"link":     link,
@@ -20,37 +57,61 @@ func Add(c *client.Client, link string, validity *time.Time) (ok string, err err
 
 	// Send request
 	result := c.Send(
-		strings.Join([]string{c.GetEndpoint(), Endpoint, "/add"}, ""),
+		c.URL(Endpoint, "/add"),
 		http.MethodPost,
 		payload,
+		options...,
 	).WithToken()
 	if result.Err != nil {
 		c.Logger.Error(nil, fmt.Sprintf(
 			"failed to add short link, sender error: %s", result.Err.Error(),
 		))
-		return "", result.Err
+		return "", time.Time{}, false, result.Err
 	}
 
 	// Check status code
-	if !result.OK() {
-		c.Logger.Error(nil, fmt.Sprintf(
-			"failed to add short link, upstream failed: code: %d, msg: %s", result.Code, result.Msg,
-		))
-		return "", fmt.Errorf("failed to add short link, upstream failed: code: %d, msg: %s", result.Code, result.Msg)
+	codes := []int{client.CodeSuccess}
+	if reuse {
+		codes = append(codes, CodeLinkExists)
+	}
+	if err = result.Expect(codes...); err != nil {
+		c.Logger.Error(nil, fmt.Sprintf("failed to add short link, %s", err.Error()))
+		return "", time.Time{}, false, fmt.Errorf("failed to add short link, %w", err)
 	}
 
 	// Build verify result struct
 	var Link struct {
-		LinkID string `json:"linkID"`
+		LinkID   string `json:"linkID"`
+		Validity int64  `json:"validity"`
 	}
 
 	// Unmarshal token data
-	if err = result.Unmarshal(&Link); err != nil {
+	if err = result.DecodeLenient(&Link); err != nil {
 		c.Logger.Error(nil, fmt.Sprintf(
-			"failed to add short link, unmarshal error: %s", result.Err.Error(),
+			"failed to add short link, unmarshal error: %s", err.Error(),
 		))
+		return "", time.Time{}, false, err
+	}
+	if Link.LinkID == "" {
+		c.Logger.Error(nil, fmt.Sprintf("failed to add short link, code %d: %s", result.Code, ErrMissingLinkID.Error()))
+		return "", time.Time{}, false, fmt.Errorf("failed to add short link, code %d: %w", result.Code, ErrMissingLinkID)
+	}
+
+	// Use validity echoed by upstream
+	effective = *validity
+	if Link.Validity > 0 {
+		effective = time.Unix(Link.Validity, 0)
+	}
+
+	return Link.LinkID, effective, result.Code == client.CodeSuccess, nil
+}
+
+// AddDefault adds a short link with the default client
+func AddDefault(link string, validity *time.Time, options ...client.CallOption) (ok string, err error) {
+	c, err := client.Default()
+	if err != nil {
 		return "", err
 	}
 
-	return Link.LinkID, nil
+	return Add(c, link, validity, options...)
 }
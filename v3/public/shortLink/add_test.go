@@ -0,0 +1,111 @@
+package shortLink
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+func TestAddEndpointSlash(t *testing.T) {
+	for _, prefix := range []string{"", "/", "/api", "/api/"} {
+		t.Run("prefix "+prefix, func(t *testing.T) {
+			var path string
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				writeEnvelope(w, client.CodeSuccess, "ok", `{"linkID":"abc"}`)
+			})
+			c := newTestClient(t, nil, client.WithEndpoint(server.URL+prefix))
+
+			validity := time.Now().Add(time.Hour)
+			if _, err := Add(c, "https://example.com", &validity); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			if want := strings.TrimSuffix(prefix, "/") + "/shortLink/add"; path != want {
+				t.Errorf("path = %s, want %s", path, want)
+			}
+		})
+	}
+}
+
+func TestAddOrGet(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        int
+		data        string
+		wantLinkID  string
+		wantCreated bool
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{"created", client.CodeSuccess, `{"linkID":"new"}`, "new", true, false, nil},
+		{"existing", CodeLinkExists, `{"linkID":"old"}`, "old", false, false, nil},
+		{"existing without ID", CodeLinkExists, `{}`, "", false, true, ErrMissingLinkID},
+		{"existing with empty ID", CodeLinkExists, `{"linkID":""}`, "", false, true, ErrMissingLinkID},
+		{"created without ID", client.CodeSuccess, `{}`, "", false, true, ErrMissingLinkID},
+		{"failed", client.CodeServerError, `null`, "", false, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeEnvelope(w, tt.code, "msg", tt.data)
+			})
+
+			validity := time.Now().Add(time.Hour)
+			linkID, created, err := AddOrGet(c, "https://example.com", &validity)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddOrGet() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("AddOrGet() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if linkID != tt.wantLinkID || created != tt.wantCreated {
+				t.Errorf("AddOrGet() = %q, %v, want %q, %v", linkID, created, tt.wantLinkID, tt.wantCreated)
+			}
+		})
+	}
+
+	t.Run("add rejects existing", func(t *testing.T) {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeEnvelope(w, CodeLinkExists, "exists", `{"linkID":"old"}`)
+		})
+
+		validity := time.Now().Add(time.Hour)
+		if _, err := Add(c, "https://example.com", &validity); err == nil {
+			t.Error("Add() error = nil, want error of existing link")
+		}
+	})
+}
+
+func TestAddURL(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, client.CodeSuccess, "ok", `{"linkID":"abc"}`)
+	}, client.WithShortDomain("go.example.com/s"))
+
+	validity := time.Now().Add(time.Hour)
+	shortURL, err := AddURL(c, "https://example.com", &validity)
+	if err != nil {
+		t.Fatalf("AddURL() error = %v", err)
+	}
+	if shortURL != "https://go.example.com/s/abc" {
+		t.Errorf("AddURL() = %s, want https://go.example.com/s/abc", shortURL)
+	}
+}
+
+func TestAddStrictDecoding(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, client.CodeSuccess, "ok", `{"linkID":"abc","validity":0,"createdAt":1}`)
+	}, client.WithStrictDecoding(true))
+
+	validity := time.Now().Add(time.Hour)
+	linkID, err := Add(c, "https://example.com", &validity)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if linkID != "abc" {
+		t.Errorf("Add() = %q, want abc", linkID)
+	}
+}
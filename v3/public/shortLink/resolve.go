@@ -0,0 +1,38 @@
+package shortLink
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+// Resolve returns the target link of a short link from its redirect without following it
+func Resolve(c *client.Client, linkID string, options ...client.CallOption) (link string, err error) {
+	// Send request
+	options = append(options[:len(options):len(options)], client.WithCallFollowRedirects(false))
+	result := c.Send(
+		c.URL(Endpoint, url.PathEscape(linkID)),
+		http.MethodGet,
+		nil,
+		options...,
+	).WithToken()
+	if result.Err != nil {
+		c.Logger.Error(nil, fmt.Sprintf(
+			"failed to resolve short link, sender error: %s", result.Err.Error(),
+		))
+		return "", result.Err
+	}
+
+	// Check redirect
+	link = result.Header.Get("Location")
+	if link == "" {
+		c.Logger.Error(nil, fmt.Sprintf(
+			"failed to resolve short link, upstream failed: code: %d, msg: %s", result.Code, result.Msg,
+		))
+		return "", fmt.Errorf("failed to resolve short link, upstream failed: code: %d, msg: %s", result.Code, result.Msg)
+	}
+
+	return link, nil
+}
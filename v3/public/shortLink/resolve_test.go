@@ -0,0 +1,45 @@
+package shortLink
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		linkID   string
+		wantPath string
+	}{
+		{"plain", "abc", "/shortLink/abc"},
+		{"reserved characters", "a/b?c#d", "/shortLink/a%2Fb%3Fc%23d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != tt.wantPath {
+					t.Errorf("path = %s, want %s", r.URL.EscapedPath(), tt.wantPath)
+				}
+				http.Redirect(w, r, "https://example.com/target", http.StatusFound)
+			})
+
+			link, err := Resolve(c, tt.linkID)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if link != "https://example.com/target" {
+				t.Errorf("Resolve() = %s, want https://example.com/target", link)
+			}
+		})
+	}
+}
+
+func TestResolveWithoutRedirect(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEnvelope(w, http.StatusNotFound, "not found", "null")
+	})
+
+	if _, err := Resolve(c, "abc"); err == nil {
+		t.Fatal("Resolve() error = nil, want error")
+	}
+}
@@ -1,3 +1,20 @@
 package shortLink
 
+import (
+	"errors"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
 const Endpoint = "/shortLink"
+
+// CodeLinkExists is the API code of adding a link already shortened, reported with the existing link ID
+const CodeLinkExists = 409
+
+// ErrMissingLinkID is returned when upstream accepts a link without reporting its link ID,
+// as no link can be looked up by its target
+var ErrMissingLinkID = errors.New("link ID not reported")
+
+func init() {
+	client.RegisterEndpoint("shortLink", Endpoint)
+}
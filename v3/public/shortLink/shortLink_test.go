@@ -0,0 +1,57 @@
+package shortLink
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+// writeEnvelope writes a response envelope of code, msg and data
+func writeEnvelope(w http.ResponseWriter, code int, msg string, data string) {
+	w.Header().Set("Content-Type", client.ContentTypeJSON)
+	_, _ = fmt.Fprintf(w, `{"code":%d,"msg":%q,"data":%s}`, code, msg, data)
+}
+
+// newTestServer starts a server answering token requests under any path prefix with a token and
+// other requests with handler
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, client.TokenEndpoint) {
+			writeEnvelope(w, client.CodeSuccess, "ok", `{"token":"test-token-0123456789"}`)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestClient starts a server answering token requests with a token and other requests with
+// handler, and creates a client of it
+func newTestClient(t *testing.T, handler http.HandlerFunc, options ...client.Option) *client.Client {
+	t.Helper()
+
+	server := newTestServer(t, handler)
+	options = append([]client.Option{
+		client.WithEndpoint(server.URL),
+		client.WithLogger(client.NewLogger(client.WithLoggerOutput(io.Discard))),
+		client.WithRetryDelay(0),
+	}, options...)
+	c, err := client.NewClient("id", "key", options...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	return c
+}
@@ -0,0 +1,12 @@
+module go.gh.ink/openapi/sdk/20260422/v3/validator/jsonschema
+
+go 1.25.0
+
+require (
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2
+	go.gh.ink/openapi/sdk/20260422/v3 v3.0.0
+)
+
+require golang.org/x/text v0.36.0 // indirect
+
+replace go.gh.ink/openapi/sdk/20260422/v3 => ../..
@@ -0,0 +1,55 @@
+package jsonschema
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+// Validator validates response data against a compiled JSON schema, it is a client.ResponseValidator
+type Validator struct {
+	schema *jsonschema.Schema
+}
+
+// Compile compiles a JSON schema, naming it by path in errors
+func Compile(path string, schema []byte) (*Validator, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid response schema of %s: %w", path, err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err = compiler.AddResource(path, doc); err != nil {
+		return nil, fmt.Errorf("invalid response schema of %s: %w", path, err)
+	}
+	compiled, err := compiler.Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response schema of %s: %w", path, err)
+	}
+
+	return &Validator{schema: compiled}, nil
+}
+
+// Validate checks data matches the schema
+func (v *Validator) Validate(data []byte) error {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	return v.schema.Validate(doc)
+}
+
+// ResponseSchema compiles a JSON schema and returns the option validating the data of successful
+// responses of the endpoint path against it, e.g. "/shortLink/add", failing with
+// *client.SchemaError on mismatch
+func ResponseSchema(path string, schema []byte) (client.Option, error) {
+	validator, err := Compile(path, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.WithResponseValidator(path, validator), nil
+}
@@ -0,0 +1,84 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+const linkSchema = `{
+	"type": "object",
+	"required": ["linkID"],
+	"properties": {"linkID": {"type": "string"}}
+}`
+
+func TestResponseSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		data    string
+		wantErr bool
+	}{
+		{"match", "/shortLink/add", `{"linkID":"abc"}`, false},
+		{"missing field", "/shortLink/add", `{}`, true},
+		{"wrong type", "/shortLink/add", `{"linkID":1}`, true},
+		{"other path", "/shortLink/resolve", `{}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", client.ContentTypeJSON)
+				_, _ = fmt.Fprintf(w, `{"code":%d,"msg":"ok","data":%s}`, client.CodeSuccess, tt.data)
+			}))
+			t.Cleanup(server.Close)
+
+			option, err := ResponseSchema("/shortLink/add", []byte(linkSchema))
+			if err != nil {
+				t.Fatalf("ResponseSchema() error = %v", err)
+			}
+			c, err := client.NewClient("id", "key",
+				client.WithEndpoint(server.URL),
+				client.WithLogger(client.NewLogger(client.WithLoggerOutput(io.Discard))),
+				client.EnableToken(false),
+				option,
+			)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			t.Cleanup(func() {
+				_ = c.Close()
+			})
+
+			result := c.Send(c.URL(tt.path), http.MethodGet, nil).WithKey()
+			var schemaErr *client.SchemaError
+			if got := errors.As(result.Err, &schemaErr); got != tt.wantErr {
+				t.Fatalf("Send() error = %v, want SchemaError %v", result.Err, tt.wantErr)
+			}
+			if tt.wantErr && schemaErr.Path != "/shortLink/add" {
+				t.Errorf("SchemaError.Path = %q, want %q", schemaErr.Path, "/shortLink/add")
+			}
+		})
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"malformed", `{`},
+		{"invalid type", `{"type":"link"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile("/shortLink/add", []byte(tt.schema)); err == nil {
+				t.Error("Compile() error = nil, want invalid schema")
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.gh.ink/openapi/sdk/20260422/v3/client"
+)
+
+var (
+	// ErrMissingSignature is returned when the signature or timestamp header is absent
+	ErrMissingSignature = errors.New("webhook signature or timestamp header is missing")
+
+	// ErrStaleTimestamp is returned when the timestamp is outside of Tolerance, preventing replay
+	ErrStaleTimestamp = errors.New("webhook timestamp is outside of tolerance")
+)
+
+// Verify verifies the webhook signature, which is the hex encoded HMAC-SHA256 of
+// "<timestamp>.<body>" keyed by secret, and checks the timestamp against replay
+func Verify(secret string, header http.Header, body []byte) (bool, error) {
+	// Read headers
+	signature := strings.TrimPrefix(header.Get(SignatureHeader), "sha256=")
+	timestamp := header.Get(TimestampHeader)
+	if signature == "" || timestamp == "" {
+		return false, ErrMissingSignature
+	}
+
+	// Check timestamp
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false, err
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > Tolerance || age < -Tolerance {
+		return false, ErrStaleTimestamp
+	}
+
+	// Decode signature
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false, err
+	}
+
+	// Compute signature
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), expected), nil
+}
+
+// Parse unmarshals a webhook body into v with the codec of the default client,
+// falling back to encoding/json when no default client is set
+func Parse(body []byte, v any) error {
+	c, err := client.Default()
+	if err != nil {
+		return json.Unmarshal(body, v)
+	}
+
+	return c.Codec(client.ContentTypeJSON).Unmarshal(body, v)
+}
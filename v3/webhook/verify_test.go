@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testSecret is the secret signing test webhooks
+const testSecret = "webhook-secret"
+
+// sign returns headers signing body at timestamp with testSecret
+func sign(timestamp time.Time, body []byte) http.Header {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+
+	header := http.Header{}
+	header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	header.Set(TimestampHeader, ts)
+	return header
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"link.created"}`)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    []byte
+		want    bool
+		wantErr error
+	}{
+		{"valid", sign(now, body), body, true, nil},
+		{"tampered body", sign(now, body), []byte(`{"event":"link.deleted"}`), false, nil},
+		{"bad hex", func() http.Header {
+			header := sign(now, body)
+			header.Set(SignatureHeader, "sha256=zz")
+			return header
+		}(), body, false, hex.InvalidByteError('z')},
+		{"missing signature", func() http.Header {
+			header := sign(now, body)
+			header.Del(SignatureHeader)
+			return header
+		}(), body, false, ErrMissingSignature},
+		{"missing timestamp", func() http.Header {
+			header := sign(now, body)
+			header.Del(TimestampHeader)
+			return header
+		}(), body, false, ErrMissingSignature},
+		{"stale timestamp", sign(now.Add(-Tolerance-time.Minute), body), body, false, ErrStaleTimestamp},
+		{"future timestamp", sign(now.Add(Tolerance+time.Minute), body), body, false, ErrStaleTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Verify(testSecret, tt.header, tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package webhook
+
+import (
+	"time"
+)
+
+// Headers carrying the webhook signature and its timestamp
+const (
+	SignatureHeader = "X-Ghink-Signature"
+	TimestampHeader = "X-Ghink-Timestamp"
+)
+
+// Tolerance is the maximum age of a webhook timestamp accepted by Verify
+const Tolerance = 5 * time.Minute